@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	DefaultLogLevel   = zapcore.DebugLevel
+	DefaultLogLevel   = zapcore.InfoLevel
 	DefaultStackLevel = zapcore.ErrorLevel
 )
 
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// ConfigEnv names the environment variable that points at a config file.
+const ConfigEnv = "CONTAINSENTRY_CONFIG"
+
+// DefaultConfigFiles are looked up in the working directory and its parents up
+// to the repository root when no config file is given explicitly.
+var DefaultConfigFiles = []string{".containsentry.yaml", ".containsentry.yml"}
+
+// resolveConfigPath returns the config file named by --config or
+// CONTAINSENTRY_CONFIG, falling back to auto-discovery. The flag is looked up
+// before the flag set is parsed because the file has to be applied first.
+func resolveConfigPath(args []string) (string, error) {
+	if path, ok := configFlag(args); ok {
+		return path, nil
+	}
+	if path := strings.TrimSpace(os.Getenv(ConfigEnv)); path != "" {
+		return path, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return discoverConfig(wd), nil
+}
+
+func configFlag(args []string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return strings.TrimSpace(value), true
+		}
+		if i+1 < len(args) {
+			return strings.TrimSpace(args[i+1]), true
+		}
+	}
+	return "", false
+}
+
+// discoverConfig walks from dir up to the repository root. Outside a git
+// repository only dir itself is searched.
+func discoverConfig(dir string) string {
+	dirs := []string{dir}
+	if root := repositoryRoot(dir); root != "" {
+		for current := dir; current != root; {
+			parent := filepath.Dir(current)
+			if parent == current {
+				break
+			}
+			dirs = append(dirs, parent)
+			current = parent
+		}
+	}
+
+	for _, candidateDir := range dirs {
+		for _, name := range DefaultConfigFiles {
+			path := filepath.Join(candidateDir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func repositoryRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		current = parent
+	}
+}
+
+func loadConfigFile(cfg *ApplicationSettings, path string) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file %q: %w", path, err)
+	}
+	if err := yaml.Unmarshal(payload, cfg); err != nil {
+		return fmt.Errorf("unmarshal config file %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// List is a string list that accepts a YAML sequence as well as a
+// comma-separated scalar, so single-value keys such as `target: compose`
+// keep working next to `target: [dockerfile, compose]`.
+type List []string
+
+func (l *List) UnmarshalText(text []byte) error {
+	*l = splitCommaSeparated(string(text))
+	return nil
+}
+
+func (l *List) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return l.UnmarshalText([]byte(node.Value))
+	case yaml.SequenceNode:
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+		*l = splitCommaSeparated(strings.Join(items, ","))
+		return nil
+	default:
+		return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+	}
+}
+
+func (l List) String() string {
+	return strings.Join(l, ",")
+}
@@ -14,9 +14,14 @@ func NewLogger(opts ...Option) *zap.Logger {
 		zap.AddStacktrace(logOpts.cfg.StackLevel),
 	)
 
+	encoder := zapcore.NewConsoleEncoder(newConsoleEncoderConfig())
+	if logOpts.format == LogFormatJSON {
+		encoder = zapcore.NewJSONEncoder(newEncoderConfig())
+	}
+
 	logger := zap.New(
 		zapcore.NewCore(
-			zapcore.NewJSONEncoder(newEncoderConfig()),
+			encoder,
 			logOpts.output,
 			logOpts.cfg.Level,
 		),
@@ -26,6 +31,18 @@ func NewLogger(opts ...Option) *zap.Logger {
 	return logger
 }
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+func newConsoleEncoderConfig() zapcore.EncoderConfig {
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05")
+	encoderConfig.CallerKey = zapcore.OmitKey
+	return encoderConfig
+}
+
 func newEncoderConfig() zapcore.EncoderConfig {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
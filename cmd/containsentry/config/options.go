@@ -34,8 +34,25 @@ func WithAtomicLevel(level AtomicLevel) Option {
 	}
 }
 
+// WithFormat selects the log encoding: LogFormatJSON writes structured
+// entries to stdout, anything else a console encoding to stderr.
+func WithFormat(format string) Option {
+	return func(x *options) {
+		x.format = format
+	}
+}
+
+// WithOutput overrides where log entries are written, e.g. to keep stdout
+// free for a machine-readable report.
+func WithOutput(w io.Writer) Option {
+	return func(x *options) {
+		x.output = lockSyncer(w)
+	}
+}
+
 type options struct {
-	cfg Config
+	cfg    Config
+	format string
 
 	zOpts       []zap.Option
 	output      zapcore.WriteSyncer
@@ -51,7 +68,11 @@ func applyOptions(opts []Option) options {
 	for _, opt := range opts {
 		opt(x)
 	}
-	x.output = firstSyncer(x.output, lockSyncer(os.Stdout))
+	defaultOutput := os.Stderr
+	if x.format == LogFormatJSON {
+		defaultOutput = os.Stdout
+	}
+	x.output = firstSyncer(x.output, lockSyncer(defaultOutput))
 	x.errorOutput = firstSyncer(x.errorOutput, lockSyncer(os.Stderr))
 	return *x
 }
@@ -4,30 +4,95 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"slices"
+	"sort"
 	"strings"
 
 	env "github.com/caarlos0/env/v11"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"github.com/katvixlab/contain-sentry/internal/ruleset"
 )
 
+// overrideTagName is a tag no field declares: parsing with it applies
+// environment values without resetting fields to their envDefault.
+const overrideTagName = "envOverride"
+
 // ApplicationSettings defines the configuration options for the Contain Sentry.
-// Configuration can be loaded from a YAML file or environment variables.
+// Values are layered: defaults, then the YAML config file, then environment
+// variables, then CLI flags.
 type ApplicationSettings struct {
+	// ConfigPath is the YAML file the settings were read from, if any.
+	ConfigPath string `yaml:"-" env:"-"`
+	// Command is the subcommand, empty for the classic single-artifact run.
+	Command string `yaml:"-" env:"-"`
+	// Paths are the positional arguments, e.g. the roots of `scan`.
+	Paths []string `yaml:"-" env:"-"`
+
 	// Logger configuration
 	Logger Config `yaml:"logger" env:"-"`
+	// Format is the report format printed to stdout.
+	Format string `yaml:"format" env:"FORMAT" envDefault:"text"`
+	// Reports maps a report format to the file it is written to.
+	Reports map[string]string `yaml:"reports" env:"REPORTS" envSeparator:"," envKeyValSeparator:"="`
+	// LogFormat selects the text report (default) or structured JSON logs.
+	LogFormat string `yaml:"log_format" env:"LOG_FORMAT" envDefault:"text"`
+	// Policy enables, disables and re-grades loaded rules.
+	Policy ruleset.Policy `yaml:"policy"`
 
-	DockerfilePath string   `yaml:"dockerfile" env:"DOCKERFILE_PATH" envDefault:"Dockerfile"`
-	ComposeFiles   []string `yaml:"compose_files" env:"COMPOSE_FILES" envSeparator:"," envDefault:"compose.yaml"`
-	ReportJSONPath string   `yaml:"report_json" env:"REPORT_JSON"`
-	Target         string   `yaml:"target" env:"TARGET" envDefault:"dockerfile"`
-	RulesPath      string   `yaml:"rules" env:"RULES_PATH" envDefault:"dockerfile-rules.json"`
+	DockerfilePath  string   `yaml:"dockerfile" env:"DOCKERFILE_PATH" envDefault:"Dockerfile"`
+	ComposeFiles    []string `yaml:"compose_files" env:"COMPOSE_FILES" envSeparator:"," envDefault:"compose.yaml"`
+	ReportJSONPath  string   `yaml:"report_json" env:"REPORT_JSON"`
+	ReportSARIFPath string   `yaml:"report_sarif" env:"REPORT_SARIF"`
+	ReportJUnitPath string   `yaml:"report_junit" env:"REPORT_JUNIT"`
+	Targets         List     `yaml:"target" env:"TARGET"`
+	RulesPaths      List     `yaml:"rules" env:"RULES_PATH"`
+	FailOn          string   `yaml:"fail_on" env:"FAIL_ON" envDefault:"none"`
+	BaselinePath    string   `yaml:"baseline" env:"BASELINE"`
+	WriteBaseline   string   `yaml:"write_baseline" env:"WRITE_BASELINE"`
+	Exclude         List     `yaml:"exclude" env:"EXCLUDE"`
+	NoGitignore     bool     `yaml:"no_gitignore" env:"NO_GITIGNORE"`
+	// BuildArgs resolve Dockerfile ARGs, as `docker build --build-arg` does.
+	BuildArgs map[string]string `yaml:"build_args" env:"BUILD_ARGS" envSeparator:"," envKeyValSeparator:"="`
 }
 
+const (
+	CommandScan      = "scan"
+	CommandRulesLint = "rules lint"
+	CommandRulesTest = "rules test"
+)
+
 func LoadApplicationSettings(args []string, stdout io.Writer, stderr io.Writer) (*ApplicationSettings, bool, error) {
 	cfg := &ApplicationSettings{
 		Logger: NewDefaultConfig(),
 	}
+	switch {
+	case len(args) > 0 && args[0] == CommandScan:
+		cfg.Command = CommandScan
+		args = args[1:]
+	case len(args) > 0 && args[0] == "rules":
+		if len(args) < 2 || (args[1] != "lint" && args[1] != "test") {
+			return nil, false, fmt.Errorf("unknown rules command: expected `containsentry rules lint|test [file ...]`")
+		}
+		cfg.Command = "rules " + args[1]
+		args = args[2:]
+	}
+
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return nil, false, err
+	}
+
+	configPath, err := resolveConfigPath(args)
+	if err != nil {
+		return nil, false, err
+	}
+	if configPath != "" {
+		if err := loadConfigFile(cfg, configPath); err != nil {
+			return nil, false, err
+		}
+		cfg.ConfigPath = configPath
+	}
 
-	if err := env.Parse(cfg); err != nil {
+	if err := env.ParseWithOptions(cfg, env.Options{DefaultValueTagName: overrideTagName}); err != nil {
 		return nil, false, err
 	}
 
@@ -35,6 +100,10 @@ func LoadApplicationSettings(args []string, stdout io.Writer, stderr io.Writer)
 	if err != nil {
 		return nil, false, err
 	}
+	// A scan analyzes every discovered target unless narrowed explicitly.
+	if cfg.Command == "" && len(cfg.Targets) == 0 {
+		cfg.Targets = List{"dockerfile"}
+	}
 
 	return cfg, helpRequested, nil
 }
@@ -50,20 +119,47 @@ func applyCLIFlags(cfg *ApplicationSettings, args []string, stdout io.Writer, st
 		writeHelp(stdout, fs)
 	}
 
-	target := fs.String("target", cfg.Target, "analysis target: dockerfile or compose")
+	fs.String("config", cfg.ConfigPath, "path to YAML config file (default: auto-discover .containsentry.yaml)")
+	target := fs.String("target", cfg.Targets.String(), "comma-separated analysis targets: dockerfile, compose")
 	dockerfilePath := fs.String("dockerfile", cfg.DockerfilePath, "path to Dockerfile")
 	composeFiles := fs.String("compose-files", strings.Join(cfg.ComposeFiles, ","), "comma-separated compose files")
-	rulesPath := fs.String("rules", cfg.RulesPath, "path to rules JSON file")
+	rulesPath := fs.String("rules", cfg.RulesPaths.String(), "comma-separated rules files, directories or globs added to the built-in rules of each target; same IDs override (JSON or YAML)")
 	reportJSONPath := fs.String("report-json", cfg.ReportJSONPath, "write findings report to JSON file")
+	reportSARIF := fs.String("report-sarif", cfg.ReportSARIFPath, "write findings report to SARIF 2.1.0 file")
+	reportJUnit := fs.String("report-junit", cfg.ReportJUnitPath, "write evaluated rules as JUnit XML testcases")
+	baselinePath := fs.String("baseline", cfg.BaselinePath, "report only findings that are not recorded in the baseline file")
+	writeBaseline := fs.String("write-baseline", cfg.WriteBaseline, "write current findings to a baseline file and exit")
+	enable := fs.String("enable", strings.Join(cfg.Policy.Enable, ","), "comma-separated rule selectors to run exclusively: IDs, globs (DF01*) or tags")
+	disable := fs.String("disable", strings.Join(cfg.Policy.Disable, ","), "comma-separated rule selectors to skip: IDs, globs (DF01*) or tags")
+	severity := fs.String("severity", joinKeyValues(cfg.Policy.Severity), "comma-separated severity overrides, e.g. DF002=fail,tag:secrets=warn")
+	exclude := fs.String("exclude", cfg.Exclude.String(), "scan: comma-separated .gitignore-style patterns to skip")
+	noGitignore := fs.Bool("no-gitignore", cfg.NoGitignore, "scan: do not honor .gitignore files")
+	format := fs.String("format", cfg.Format, "report format printed to stdout: text, json, sarif, junit, gitlab-codequality or checkstyle")
+	reports := &keyValueFlag{values: cfg.Reports}
+	fs.Var(reports, "report", "write a report file as FORMAT=PATH, e.g. sarif=out.sarif; repeatable")
+	buildArgs := &keyValueFlag{values: cfg.BuildArgs}
+	fs.Var(buildArgs, "build-arg", "set a Dockerfile ARG value as KEY=VALUE; repeatable")
+	logFormat := fs.String("log-format", cfg.LogFormat, "output format: text (human-readable report, logs on stderr) or json (structured log lines on stdout)")
+	failOn := fs.String("fail-on", cfg.FailOn, "exit non-zero when a finding reaches severity: none, any, warn or fail")
 	help := fs.Bool("help", false, "show help")
 	fs.BoolVar(help, "h", false, "show help")
 
-	if err := fs.Parse(args); err != nil {
-		if err == flag.ErrHelp {
-			writeHelp(stdout, fs)
-			return true, nil
+	// Positional arguments may be interleaved with flags: scan ./ --fail-on fail.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				writeHelp(stdout, fs)
+				return true, nil
+			}
+			return false, err
 		}
-		return false, err
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
 
 	if *help {
@@ -71,15 +167,61 @@ func applyCLIFlags(cfg *ApplicationSettings, args []string, stdout io.Writer, st
 		return true, nil
 	}
 
-	cfg.Target = strings.TrimSpace(*target)
+	cfg.Targets = splitCommaSeparated(strings.ToLower(*target))
 	cfg.DockerfilePath = strings.TrimSpace(*dockerfilePath)
-	cfg.RulesPath = strings.TrimSpace(*rulesPath)
+	cfg.RulesPaths = splitCommaSeparated(*rulesPath)
 	cfg.ReportJSONPath = strings.TrimSpace(*reportJSONPath)
+	cfg.ReportSARIFPath = strings.TrimSpace(*reportSARIF)
+	cfg.ReportJUnitPath = strings.TrimSpace(*reportJUnit)
 	cfg.ComposeFiles = splitCommaSeparated(*composeFiles)
+	cfg.FailOn = strings.TrimSpace(*failOn)
+	cfg.LogFormat = strings.ToLower(strings.TrimSpace(*logFormat))
+	cfg.Format = strings.ToLower(strings.TrimSpace(*format))
+	cfg.Reports = reports.values
+	cfg.BaselinePath = strings.TrimSpace(*baselinePath)
+	cfg.WriteBaseline = strings.TrimSpace(*writeBaseline)
+
+	cfg.Exclude = splitCommaSeparated(*exclude)
+	cfg.NoGitignore = *noGitignore
+	cfg.BuildArgs = buildArgs.values
+	cfg.Paths = positional
+	if cfg.Command == "" && len(cfg.Paths) > 0 {
+		return false, fmt.Errorf("unexpected arguments %v: use `containsentry scan <path>` to analyze a directory", cfg.Paths)
+	}
+
+	cfg.Policy.Enable = splitCommaSeparated(*enable)
+	cfg.Policy.Disable = splitCommaSeparated(*disable)
+	overrides, err := splitKeyValues(*severity)
+	if err != nil {
+		return false, err
+	}
+	cfg.Policy.Severity = overrides
+
+	if _, err := entities.ParseThreshold(cfg.FailOn); err != nil {
+		return false, err
+	}
+	if err := validateTargets(cfg.Targets); err != nil {
+		return false, err
+	}
+	if cfg.LogFormat != LogFormatText && cfg.LogFormat != LogFormatJSON {
+		return false, fmt.Errorf("unknown log format %q: expected %s or %s", cfg.LogFormat, LogFormatText, LogFormatJSON)
+	}
 
 	return false, nil
 }
 
+// KnownTargets lists the analysis targets accepted by --target.
+var KnownTargets = []string{"dockerfile", "compose"}
+
+func validateTargets(targets []string) error {
+	for _, target := range targets {
+		if !slices.Contains(KnownTargets, target) {
+			return fmt.Errorf("unknown analysis target %q: expected one of %s", target, strings.Join(KnownTargets, ", "))
+		}
+	}
+	return nil
+}
+
 func splitCommaSeparated(input string) []string {
 	parts := strings.Split(input, ",")
 	items := make([]string, 0, len(parts))
@@ -93,6 +235,65 @@ func splitCommaSeparated(input string) []string {
 	return items
 }
 
+func joinKeyValues(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]string, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, key+"="+values[key])
+	}
+	return strings.Join(items, ",")
+}
+
+func splitKeyValues(input string) (map[string]string, error) {
+	items := splitCommaSeparated(input)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid key=value pair %q", item)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// keyValueFlag collects repeated KEY=VALUE flags. The first occurrence
+// replaces values inherited from the config file or environment.
+type keyValueFlag struct {
+	values map[string]string
+	set    bool
+}
+
+func (f *keyValueFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return joinKeyValues(f.values)
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid key=value pair %q", value)
+	}
+	if !f.set {
+		f.values = map[string]string{}
+		f.set = true
+	}
+	f.values[key] = val
+	return nil
+}
+
 func writeHelp(output io.Writer, fs *flag.FlagSet) {
 	if output == nil {
 		return
@@ -101,15 +302,39 @@ func writeHelp(output io.Writer, fs *flag.FlagSet) {
 	_, _ = fmt.Fprintln(output, "")
 	_, _ = fmt.Fprintln(output, "Usage:")
 	_, _ = fmt.Fprintln(output, "  containsentry [flags]")
+	_, _ = fmt.Fprintln(output, "  containsentry scan [path ...] [flags]")
+	_, _ = fmt.Fprintln(output, "  containsentry rules lint [rules-file ...] [flags]")
+	_, _ = fmt.Fprintln(output, "  containsentry rules test [rules-file ...] [flags]")
 	_, _ = fmt.Fprintln(output, "")
 	_, _ = fmt.Fprintln(output, "Flags:")
 	fs.SetOutput(output)
 	fs.PrintDefaults()
 	_, _ = fmt.Fprintln(output, "")
 	_, _ = fmt.Fprintln(output, "Environment variables:")
+	_, _ = fmt.Fprintln(output, "  CONTAINSENTRY_CONFIG")
 	_, _ = fmt.Fprintln(output, "  TARGET")
 	_, _ = fmt.Fprintln(output, "  DOCKERFILE_PATH")
 	_, _ = fmt.Fprintln(output, "  COMPOSE_FILES")
 	_, _ = fmt.Fprintln(output, "  RULES_PATH")
 	_, _ = fmt.Fprintln(output, "  REPORT_JSON")
+	_, _ = fmt.Fprintln(output, "  REPORT_SARIF")
+	_, _ = fmt.Fprintln(output, "  REPORT_JUNIT")
+	_, _ = fmt.Fprintln(output, "  REPORTS")
+	_, _ = fmt.Fprintln(output, "  FORMAT")
+	_, _ = fmt.Fprintln(output, "  FAIL_ON")
+	_, _ = fmt.Fprintln(output, "  BASELINE")
+	_, _ = fmt.Fprintln(output, "  WRITE_BASELINE")
+	_, _ = fmt.Fprintln(output, "  ENABLE_RULES")
+	_, _ = fmt.Fprintln(output, "  DISABLE_RULES")
+	_, _ = fmt.Fprintln(output, "  SEVERITY_OVERRIDES")
+	_, _ = fmt.Fprintln(output, "  EXCLUDE")
+	_, _ = fmt.Fprintln(output, "  NO_GITIGNORE")
+	_, _ = fmt.Fprintln(output, "  BUILD_ARGS")
+	_, _ = fmt.Fprintln(output, "  LOG_FORMAT")
+	_, _ = fmt.Fprintln(output, "")
+	_, _ = fmt.Fprintln(output, "Exit codes:")
+	_, _ = fmt.Fprintln(output, "  0  no findings reached the --fail-on threshold")
+	_, _ = fmt.Fprintln(output, "  1  configuration, loading or analysis error")
+	_, _ = fmt.Fprintln(output, "  2  gate failed, highest finding severity is below fail")
+	_, _ = fmt.Fprintln(output, "  3  gate failed, at least one fail finding")
 }
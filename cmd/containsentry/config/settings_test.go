@@ -2,7 +2,11 @@ package config
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLoadApplicationSettingsCLIOverrides(t *testing.T) {
@@ -21,8 +25,8 @@ func TestLoadApplicationSettingsCLIOverrides(t *testing.T) {
 	if help {
 		t.Fatalf("help = true, want false")
 	}
-	if cfg.Target != "compose" {
-		t.Fatalf("Target = %q, want compose", cfg.Target)
+	if cfg.Targets.String() != "compose" {
+		t.Fatalf("Targets = %v, want [compose]", cfg.Targets)
 	}
 	if len(cfg.ComposeFiles) != 2 {
 		t.Fatalf("ComposeFiles len = %d, want 2", len(cfg.ComposeFiles))
@@ -30,8 +34,8 @@ func TestLoadApplicationSettingsCLIOverrides(t *testing.T) {
 	if cfg.ComposeFiles[0] != "compose.yaml" || cfg.ComposeFiles[1] != "compose.prod.yaml" {
 		t.Fatalf("ComposeFiles = %v", cfg.ComposeFiles)
 	}
-	if cfg.RulesPath != "compose-rules.json" {
-		t.Fatalf("RulesPath = %q, want compose-rules.json", cfg.RulesPath)
+	if cfg.RulesPaths.String() != "compose-rules.json" {
+		t.Fatalf("RulesPaths = %v, want compose-rules.json", cfg.RulesPaths)
 	}
 	if cfg.ReportJSONPath != "out.json" {
 		t.Fatalf("ReportJSONPath = %q, want out.json", cfg.ReportJSONPath)
@@ -53,3 +57,296 @@ func TestLoadApplicationSettingsHelp(t *testing.T) {
 		t.Fatalf("stdout is empty, want help output")
 	}
 }
+
+func TestLoadApplicationSettingsRejectsUnknownFailOn(t *testing.T) {
+	_, _, err := LoadApplicationSettings([]string{"--fail-on", "sometimes"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func writeConfigFile(t *testing.T, dir string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".containsentry.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+const testConfigFile = `
+target: compose
+rules: file-rules.json
+compose_files: [file.compose.yaml]
+fail_on: warn
+logger:
+  level: warn
+policy:
+  disable: [CP031]
+  severity:
+    CP024: fail
+`
+
+func TestLoadApplicationSettingsLayersFileEnvAndFlags(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), testConfigFile)
+	t.Setenv("RULES_PATH", "env-rules.json")
+	t.Setenv("FAIL_ON", "fail")
+
+	cfg, _, err := LoadApplicationSettings([]string{"--config", path, "--fail-on", "any"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.ConfigPath != path {
+		t.Fatalf("ConfigPath = %q, want %q", cfg.ConfigPath, path)
+	}
+	if cfg.Targets.String() != "compose" {
+		t.Fatalf("Targets = %v, want compose from file", cfg.Targets)
+	}
+	if len(cfg.ComposeFiles) != 1 || cfg.ComposeFiles[0] != "file.compose.yaml" {
+		t.Fatalf("ComposeFiles = %v, want file value", cfg.ComposeFiles)
+	}
+	if cfg.RulesPaths.String() != "env-rules.json" {
+		t.Fatalf("RulesPaths = %v, want env override", cfg.RulesPaths)
+	}
+	if cfg.FailOn != "any" {
+		t.Fatalf("FailOn = %q, want flag override", cfg.FailOn)
+	}
+	if cfg.DockerfilePath != "Dockerfile" {
+		t.Fatalf("DockerfilePath = %q, want default", cfg.DockerfilePath)
+	}
+	if cfg.Logger.Level.Level() != zapcore.WarnLevel {
+		t.Fatalf("logger level = %s, want warn", cfg.Logger.Level.Level())
+	}
+	if len(cfg.Policy.Disable) != 1 || cfg.Policy.Severity["CP024"] != "fail" {
+		t.Fatalf("Policy = %+v", cfg.Policy)
+	}
+}
+
+func TestLoadApplicationSettingsDiscoversConfigInRepositoryRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir .git: %v", err)
+	}
+	nested := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir nested: %v", err)
+	}
+	path := writeConfigFile(t, root, testConfigFile)
+	t.Chdir(nested)
+
+	cfg, _, err := LoadApplicationSettings(nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.ConfigPath != path {
+		t.Fatalf("ConfigPath = %q, want %q", cfg.ConfigPath, path)
+	}
+	if cfg.RulesPaths.String() != "file-rules.json" {
+		t.Fatalf("RulesPaths = %v, want file value", cfg.RulesPaths)
+	}
+}
+
+func TestLoadApplicationSettingsMissingExplicitConfig(t *testing.T) {
+	_, _, err := LoadApplicationSettings([]string{"--config=missing.yaml"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsPolicyFlagsAndEnv(t *testing.T) {
+	t.Setenv("DISABLE_RULES", "DF015,tag:build-cache")
+	t.Setenv("SEVERITY_OVERRIDES", "DF002=info")
+
+	cfg, _, err := LoadApplicationSettings([]string{
+		"--enable", "DF0*",
+		"--severity", "DF002=fail,tag:secrets=warn",
+	}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if len(cfg.Policy.Enable) != 1 || cfg.Policy.Enable[0] != "DF0*" {
+		t.Fatalf("Enable = %v, want flag value", cfg.Policy.Enable)
+	}
+	if len(cfg.Policy.Disable) != 2 || cfg.Policy.Disable[1] != "tag:build-cache" {
+		t.Fatalf("Disable = %v, want env value", cfg.Policy.Disable)
+	}
+	if cfg.Policy.Severity["DF002"] != "fail" || cfg.Policy.Severity["tag:secrets"] != "warn" {
+		t.Fatalf("Severity = %v, want flag overrides", cfg.Policy.Severity)
+	}
+}
+
+func TestLoadApplicationSettingsRejectsMalformedSeverity(t *testing.T) {
+	_, _, err := LoadApplicationSettings([]string{"--severity", "DF002"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsBuildArgs(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), `
+build_args:
+  TAG: "1.0"
+  APP_UID: "10001"
+`)
+
+	cfg, _, err := LoadApplicationSettings([]string{"--config", path}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.BuildArgs["TAG"] != "1.0" || cfg.BuildArgs["APP_UID"] != "10001" {
+		t.Fatalf("BuildArgs = %v, want config values", cfg.BuildArgs)
+	}
+
+	cfg, _, err = LoadApplicationSettings([]string{
+		"--config", path,
+		"--build-arg", "TAG=2.0",
+		"--build-arg", "EMPTY=",
+	}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if len(cfg.BuildArgs) != 2 || cfg.BuildArgs["TAG"] != "2.0" || cfg.BuildArgs["EMPTY"] != "" {
+		t.Fatalf("BuildArgs = %v, want flag values to replace config", cfg.BuildArgs)
+	}
+
+	if _, _, err := LoadApplicationSettings([]string{"--build-arg", "TAG"}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error for missing value")
+	}
+}
+
+func TestLoadApplicationSettingsMultipleTargets(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), `
+target: [dockerfile, compose]
+rules:
+  - dockerfile-rules.json
+  - compose-rules.json
+`)
+
+	cfg, _, err := LoadApplicationSettings([]string{"--config", path}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Targets.String() != "dockerfile,compose" {
+		t.Fatalf("Targets = %v, want [dockerfile compose]", cfg.Targets)
+	}
+	if cfg.RulesPaths.String() != "dockerfile-rules.json,compose-rules.json" {
+		t.Fatalf("RulesPaths = %v", cfg.RulesPaths)
+	}
+
+	cfg, _, err = LoadApplicationSettings([]string{"--config", path, "--target", "Compose"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Targets.String() != "compose" {
+		t.Fatalf("Targets = %v, want flag override", cfg.Targets)
+	}
+}
+
+func TestLoadApplicationSettingsRejectsUnknownTarget(t *testing.T) {
+	_, _, err := LoadApplicationSettings([]string{"--target", "dockerfile,helm"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsScanCommand(t *testing.T) {
+	cfg, _, err := LoadApplicationSettings([]string{
+		"scan", "./services", "--exclude", "vendor/,**/testdata/**", "./tools", "--fail-on", "fail",
+	}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Command != CommandScan {
+		t.Fatalf("Command = %q, want scan", cfg.Command)
+	}
+	if len(cfg.Paths) != 2 || cfg.Paths[0] != "./services" || cfg.Paths[1] != "./tools" {
+		t.Fatalf("Paths = %v", cfg.Paths)
+	}
+	if cfg.Exclude.String() != "vendor/,**/testdata/**" || cfg.FailOn != "fail" {
+		t.Fatalf("Exclude = %v, FailOn = %q", cfg.Exclude, cfg.FailOn)
+	}
+	if len(cfg.Targets) != 0 {
+		t.Fatalf("Targets = %v, want all targets for scan", cfg.Targets)
+	}
+}
+
+func TestLoadApplicationSettingsRulesCommands(t *testing.T) {
+	cfg, _, err := LoadApplicationSettings([]string{"rules", "lint", "custom-rules.json", "extra-rules.json"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Command != CommandRulesLint {
+		t.Fatalf("Command = %q, want rules lint", cfg.Command)
+	}
+	if len(cfg.Paths) != 2 || cfg.Paths[0] != "custom-rules.json" {
+		t.Fatalf("Paths = %v", cfg.Paths)
+	}
+
+	cfg, _, err = LoadApplicationSettings([]string{"rules", "test", "--rules", "custom-rules.json"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Command != CommandRulesTest || len(cfg.Paths) != 0 || cfg.RulesPaths.String() != "custom-rules.json" {
+		t.Fatalf("Command = %q, Paths = %v, RulesPaths = %v", cfg.Command, cfg.Paths, cfg.RulesPaths)
+	}
+
+	if _, _, err := LoadApplicationSettings([]string{"rules", "check"}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("LoadApplicationSettings(rules check) error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsRejectsPositionalWithoutScan(t *testing.T) {
+	_, _, err := LoadApplicationSettings([]string{"./services"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsLogFormat(t *testing.T) {
+	cfg, _, err := LoadApplicationSettings(nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.LogFormat != LogFormatText {
+		t.Fatalf("LogFormat = %q, want text by default", cfg.LogFormat)
+	}
+
+	cfg, _, err = LoadApplicationSettings([]string{"--log-format", "JSON"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.LogFormat != LogFormatJSON {
+		t.Fatalf("LogFormat = %q, want json", cfg.LogFormat)
+	}
+
+	if _, _, err := LoadApplicationSettings([]string{"--log-format", "xml"}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("LoadApplicationSettings() error = nil, want error")
+	}
+}
+
+func TestLoadApplicationSettingsReports(t *testing.T) {
+	t.Setenv("REPORTS", "json=env.json")
+
+	cfg, _, err := LoadApplicationSettings(nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Format != "text" || len(cfg.Reports) != 1 || cfg.Reports["json"] != "env.json" {
+		t.Fatalf("Format = %q, Reports = %v, want text and env reports", cfg.Format, cfg.Reports)
+	}
+
+	cfg, _, err = LoadApplicationSettings([]string{
+		"--format", "Checkstyle",
+		"--report", "sarif=out.sarif",
+		"--report", "gitlab-codequality=gl-code-quality.json",
+	}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("LoadApplicationSettings() error = %v", err)
+	}
+	if cfg.Format != "checkstyle" {
+		t.Fatalf("Format = %q, want checkstyle", cfg.Format)
+	}
+	if len(cfg.Reports) != 2 || cfg.Reports["sarif"] != "out.sarif" || cfg.Reports["gitlab-codequality"] != "gl-code-quality.json" {
+		t.Fatalf("Reports = %v, want flag values to replace env", cfg.Reports)
+	}
+}
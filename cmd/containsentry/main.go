@@ -4,16 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/katvixlab/contain-sentry/cmd/containsentry/config"
-	"github.com/katvixlab/contain-sentry/internal/compose"
-	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/engine"
 	"github.com/katvixlab/contain-sentry/internal/entities"
 	"github.com/katvixlab/contain-sentry/internal/report"
+	"github.com/katvixlab/contain-sentry/internal/ruleset"
 	"go.uber.org/zap"
 )
 
+// version is the release the binary was built from, set at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
 func main() {
 	cfg, help, err := config.LoadApplicationSettings(os.Args[1:], os.Stdout, os.Stderr)
 	if err != nil {
@@ -23,55 +29,198 @@ func main() {
 	if help {
 		return
 	}
+	switch cfg.Command {
+	case config.CommandRulesLint:
+		os.Exit(lintRules(cfg, os.Stdout, os.Stderr))
+	case config.CommandRulesTest:
+		os.Exit(testRules(cfg, os.Stdout, os.Stderr))
+	}
 
-	log := config.NewLogger(config.WithConfig(cfg.Logger))
-	log.Info(
+	logOpts := []config.Option{config.WithConfig(cfg.Logger), config.WithFormat(cfg.LogFormat)}
+	if cfg.Format != report.FormatText {
+		logOpts = append(logOpts, config.WithOutput(os.Stderr))
+	}
+	log := config.NewLogger(logOpts...)
+	log.Debug(
 		"Application config loaded",
 		zap.Any("config", cfg),
 	)
 
-	rules, err := loadRules(cfg.RulesPath)
+	failOn, err := entities.ParseThreshold(cfg.FailOn)
 	if err != nil {
-		log.Fatal("Failed to load rules", zap.Error(err), zap.String("rules", cfg.RulesPath))
+		log.Fatal("Invalid severity threshold", zap.Error(err), zap.String("fail_on", cfg.FailOn))
+	}
+
+	if _, err := report.Lookup(cfg.Format); err != nil {
+		log.Fatal("Invalid output format", zap.Error(err), zap.String("format", cfg.Format))
+	}
+	files := reportFiles(cfg)
+	for _, file := range files {
+		if _, err := report.Lookup(file.format); err != nil {
+			log.Fatal("Invalid report format", zap.Error(err), zap.String("report", file.path))
+		}
 	}
 
 	ctx := config.WithLogger(context.Background(), log)
 
-	var validate []entities.Finding
-	switch strings.ToLower(strings.TrimSpace(cfg.Target)) {
-	case "compose":
-		project, err := compose.NewProject(ctx, cfg.ComposeFiles)
-		if err != nil {
-			log.Fatal("Failed to create Compose project", zap.Error(err), zap.Strings("compose_files", cfg.ComposeFiles))
-		}
+	targets, failures, err := loadTargets(ctx, cfg)
+	if err != nil {
+		log.Fatal("Failed to load analysis targets", zap.Error(err))
+	}
 
-		findings, err := project.Validate(ctx, rules)
-		if err != nil {
-			log.Fatal("Failed to validate Compose project", zap.Error(err))
+	set, err := loadRules(cfg.RulesPaths, targetNames(targets))
+	if err != nil {
+		log.Fatal("Failed to load rules", zap.Error(err), zap.Strings("rules", cfg.RulesPaths))
+	}
+	rules, err := ruleset.Apply(set.Rules, cfg.Policy)
+	if err != nil {
+		log.Fatal("Failed to apply rule policy", zap.Error(err), zap.String("config", cfg.ConfigPath))
+	}
+
+	evaluations, err := engine.New(rules, targetRunners()...).EvaluateAll(ctx, targetDrivers(targets)...)
+	if err != nil {
+		log.Fatal("Failed to validate targets", zap.Error(err), zap.Strings("targets", targetNames(targets)))
+	}
+	validate := dropRepeatedFindings(evaluations)
+
+	if strings.TrimSpace(cfg.WriteBaseline) != "" {
+		baseline := report.NewBaseline(validate)
+		if err := report.WriteBaseline(cfg.WriteBaseline, baseline); err != nil {
+			log.Fatal("Failed to write baseline", zap.Error(err), zap.String("write_baseline", cfg.WriteBaseline))
 		}
-		validate = findings
-	default:
-		df, err := dockerfile.NewDockerfile(ctx, cfg.DockerfilePath)
+		log.Info("Baseline written", zap.String("write_baseline", cfg.WriteBaseline), zap.Int("findings", len(baseline.Findings)))
+		return
+	}
+
+	ruleSet, err := ruleSetSummary(set, rules)
+	if err != nil {
+		log.Fatal("Failed to identify rule set", zap.Error(err))
+	}
+	buildOpts := []report.Option{
+		report.WithFailOn(failOn),
+		report.WithRuleSet(ruleSet),
+		report.WithErrors(failures...),
+	}
+	for _, target := range targets {
+		buildOpts = append(buildOpts, report.WithTarget(target.name, target.artifacts...))
+	}
+	var baseline *report.Baseline
+	if strings.TrimSpace(cfg.BaselinePath) != "" {
+		baseline, err = report.LoadBaseline(cfg.BaselinePath)
 		if err != nil {
-			log.Fatal("Failed to create Dockerfile", zap.Error(err), zap.String("dockerfile", cfg.DockerfilePath))
+			log.Fatal("Failed to load baseline", zap.Error(err), zap.String("baseline", cfg.BaselinePath))
 		}
+		buildOpts = append(buildOpts, report.WithBaseline(baseline))
+	}
 
-		findings, err := df.Validate(ctx, rules)
-		if err != nil {
-			log.Fatal("Failed to validate Dockerfile", zap.Error(err))
+	rep := report.Build(validate, buildOpts...)
+	in := report.Input{
+		Report:      rep,
+		Findings:    validate,
+		Evaluations: junitArtifacts(targets, evaluations),
+		Baseline:    baseline,
+		SARIF:       report.SARIFOptions{Artifacts: sarifArtifacts(targets), ToolVersion: version},
+		Text:        report.TextOptions{Color: useColor(os.Stdout)},
+		HTML:        report.HTMLOptions{BaseDir: sourceBaseDir(cfg), Generated: time.Now()},
+		Markdown:    report.MarkdownOptions{LinkBase: markdownLinkBase(), BaseDir: sourceBaseDir(cfg), Baseline: baseline},
+	}
+	if cfg.Format == report.FormatText && cfg.LogFormat == config.LogFormatJSON {
+		logReport(log, rep)
+	} else if err := report.Write(os.Stdout, cfg.Format, in); err != nil {
+		log.Fatal("Failed to write report", zap.Error(err), zap.String("format", cfg.Format))
+	}
+
+	for _, file := range files {
+		if err := report.WriteFile(file.path, file.format, in); err != nil {
+			log.Fatal("Failed to write report", zap.Error(err), zap.String("format", file.format), zap.String("path", file.path))
 		}
-		validate = findings
+		log.Info("Report written", zap.String("format", file.format), zap.String("path", file.path))
 	}
 
-	for _, finding := range validate {
+	gate := rep.Summary.Gate
+	if cfg.Format == report.FormatText && cfg.LogFormat != config.LogFormatJSON {
+		// The text report already ends with the gate result.
+		os.Exit(gate.ExitCode)
+	}
+	if !gate.Passed {
+		log.Error(
+			"Severity gate failed",
+			zap.String("fail_on", gate.FailOn),
+			zap.String("highest", gate.Highest),
+			zap.Int("exit_code", gate.ExitCode),
+			zap.Int("errors", gate.Errors),
+		)
+		os.Exit(gate.ExitCode)
+	}
+	log.Info("Severity gate passed", zap.String("fail_on", gate.FailOn), zap.String("highest", gate.Highest))
+}
+
+// logReport emits findings as structured log entries for --log-format json.
+func logReport(log *zap.Logger, rep report.Report) {
+	for _, finding := range rep.Findings {
 		log.Info(fmt.Sprintf("[%s][%s] %s | code=%q | location=%v", finding.Severity, finding.ID, finding.Name, finding.CodeSample, finding.Location))
 	}
-	log.Info(fmt.Sprintf("Total findings: %d", len(validate)))
+	for _, finding := range rep.Suppressed {
+		log.Info(fmt.Sprintf("[suppressed][%s] %s | reason=%q | location=%v", finding.ID, finding.Name, finding.Suppression.Reason, finding.Location))
+	}
+	for _, failure := range rep.Errors {
+		log.Error(fmt.Sprintf("[error][%s] %s | files=%v", failure.Target, failure.Error, failure.Files))
+	}
+	for _, target := range rep.Summary.ByTarget {
+		log.Info(fmt.Sprintf("Target %s: findings: %d, suppressed: %d, baselined: %d", target.Target, target.Total, target.Suppressed, target.Baselined))
+	}
+	log.Info(fmt.Sprintf("Total findings: %d, suppressed: %d, baselined: %d", rep.Summary.Total, rep.Summary.Suppressed, rep.Summary.Baselined))
+}
+
+// markdownLinkBase points Markdown locations at the analyzed commit when
+// running in GitHub Actions or GitLab CI.
+func markdownLinkBase() string {
+	if server, repo, sha := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_SHA"); server != "" && repo != "" && sha != "" {
+		return server + "/" + repo + "/blob/" + sha + "/"
+	}
+	if project, sha := os.Getenv("CI_PROJECT_URL"), os.Getenv("CI_COMMIT_SHA"); project != "" && sha != "" {
+		return project + "/-/blob/" + sha + "/"
+	}
+	return ""
+}
+
+// useColor follows the NO_COLOR convention and colors only terminals.
+func useColor(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	if strings.TrimSpace(cfg.ReportJSONPath) != "" {
-		if err := report.WriteJSON(cfg.ReportJSONPath, report.Build(validate)); err != nil {
-			log.Fatal("Failed to write JSON report", zap.Error(err), zap.String("report_json", cfg.ReportJSONPath))
+type reportFile struct {
+	format string
+	path   string
+}
+
+// reportFiles merges --report FORMAT=PATH with the per-format flags such as
+// --report-json, ordered by format for a stable log.
+func reportFiles(cfg *config.ApplicationSettings) []reportFile {
+	paths := map[string]string{}
+	for format, path := range cfg.Reports {
+		if strings.TrimSpace(path) != "" {
+			paths[strings.ToLower(strings.TrimSpace(format))] = strings.TrimSpace(path)
+		}
+	}
+	for format, path := range map[string]string{
+		report.FormatJSON:  cfg.ReportJSONPath,
+		report.FormatSARIF: cfg.ReportSARIFPath,
+		report.FormatJUnit: cfg.ReportJUnitPath,
+	} {
+		if strings.TrimSpace(path) != "" {
+			paths[format] = strings.TrimSpace(path)
 		}
-		log.Info("JSON report written", zap.String("report_json", cfg.ReportJSONPath))
 	}
+
+	files := make([]reportFile, 0, len(paths))
+	for format, path := range paths {
+		files = append(files, reportFile{format: format, path: path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].format < files[j].format })
+	return files
 }
@@ -1,32 +1,150 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
+	containsentry "github.com/katvixlab/contain-sentry"
+	"github.com/katvixlab/contain-sentry/cmd/containsentry/config"
 	"github.com/katvixlab/contain-sentry/internal/entities"
+	"github.com/katvixlab/contain-sentry/internal/report"
+	"github.com/katvixlab/contain-sentry/internal/ruleset"
+	"github.com/katvixlab/contain-sentry/internal/ruletest"
 )
 
-type ruleSet struct {
-	Rules []entities.BaseRule `json:"rules"`
+// ruleSetSummary identifies the effective rules and the named packs they
+// were loaded from.
+func ruleSetSummary(set ruleset.Set, rules []entities.BaseRule) (report.RuleSetSummary, error) {
+	version, err := ruleset.Digest(rules)
+	if err != nil {
+		return report.RuleSetSummary{}, err
+	}
+	summary := report.RuleSetSummary{Version: version, Rules: len(rules)}
+	for _, pack := range set.Packs {
+		summary.Sources = append(summary.Sources, pack.File)
+		if pack.Name != "" {
+			summary.Packs = append(summary.Packs, report.RulePack{Name: pack.Name, Version: pack.Version, Source: pack.File, Rules: pack.Rules})
+		}
+	}
+	return summary, nil
+}
+
+// builtinRulesPrefix marks the embedded rule packs in reports and errors.
+const builtinRulesPrefix = "builtin:"
+
+// builtinRuleFiles names the embedded rules file of every target.
+func builtinRuleFiles(targets []string) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target+"-rules.json")
+	}
+	return names
+}
+
+// builtinRules loads the rule packs embedded in the binary for the targets.
+func builtinRules(targets []string) (ruleset.Set, error) {
+	set, err := ruleset.LoadFS(containsentry.DefaultRules, builtinRuleFiles(targets)...)
+	if err != nil {
+		return ruleset.Set{}, fmt.Errorf("load built-in rules: %w", err)
+	}
+	for i := range set.Packs {
+		set.Packs[i].File = builtinRulesPrefix + set.Packs[i].File
+	}
+	return set, nil
 }
 
-func loadRules(path string) ([]entities.BaseRule, error) {
-	payload, err := os.ReadFile(path)
+// loadRules starts from the built-in rules of the targets and lets the
+// configured rules files add rules or override built-in ones by ID.
+func loadRules(paths []string, targets []string) (ruleset.Set, error) {
+	set, err := builtinRules(targets)
+	if err != nil || len(paths) == 0 {
+		return set, err
+	}
+	custom, err := ruleset.Load(paths)
 	if err != nil {
-		return nil, fmt.Errorf("read rules file %q: %w", path, err)
+		return ruleset.Set{}, err
 	}
+	return ruleset.Merge(set, custom), nil
+}
 
-	var rules []entities.BaseRule
-	arrayErr := json.Unmarshal(payload, &rules)
-	if arrayErr == nil {
-		return rules, nil
+// rulesCommandPaths are the files of `rules lint` and `rules test`: the
+// arguments or the configured rules. Without either the commands work on the
+// built-in rules.
+func rulesCommandPaths(cfg *config.ApplicationSettings) []string {
+	if len(cfg.Paths) > 0 {
+		return cfg.Paths
 	}
+	return cfg.RulesPaths
+}
 
-	var wrapped ruleSet
-	if err := json.Unmarshal(payload, &wrapped); err != nil {
-		return nil, fmt.Errorf("unmarshal rules file %q: array_err=%v, object_err=%w", path, arrayErr, err)
+// lintRules runs `rules lint` and returns the exit code.
+func lintRules(cfg *config.ApplicationSettings, stdout io.Writer, stderr io.Writer) int {
+	paths := rulesCommandPaths(cfg)
+	var issues []ruleset.Issue
+	var err error
+	if len(paths) > 0 {
+		issues, err = ruleset.Lint(paths, ruleVocabularies())
+	} else {
+		paths = builtinRuleFiles(config.KnownTargets)
+		issues, err = ruleset.LintFS(containsentry.DefaultRules, paths, ruleVocabularies())
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to lint rules: %v\n", err)
+		return 1
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(stdout, issue)
+	}
+	if len(issues) > 0 {
+		fmt.Fprintf(stderr, "%d issue(s) found\n", len(issues))
+		return 1
+	}
+	fmt.Fprintf(stdout, "%d rules file(s) OK\n", len(paths))
+	return 0
+}
+
+// testRules runs `rules test`: every rule example goes through the driver of
+// its target and rules that do not behave as their examples claim are listed.
+func testRules(cfg *config.ApplicationSettings, stdout io.Writer, stderr io.Writer) int {
+	var set ruleset.Set
+	var err error
+	if paths := rulesCommandPaths(cfg); len(paths) > 0 {
+		set, err = ruleset.Load(paths)
+	} else {
+		set, err = builtinRules(config.KnownTargets)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load rules: %v\n", err)
+		return 1
+	}
+
+	results, err := ruletest.Run(context.Background(), set.Rules)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to test rules: %v\n", err)
+		return 1
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			continue
+		}
+		failed++
+		fmt.Fprintf(stdout, "FAIL %s\n", result)
+		for _, line := range strings.Split(strings.TrimRight(result.Example, "\n"), "\n") {
+			fmt.Fprintf(stdout, "    | %s\n", line)
+		}
+	}
+
+	tested := map[string]struct{}{}
+	for _, result := range results {
+		tested[result.RuleID] = struct{}{}
+	}
+	fmt.Fprintf(stdout, "%d example(s) of %d rule(s): %d passed, %d failed; %d rule(s) without examples\n",
+		len(results), len(tested), len(results)-failed, failed, len(set.Rules)-len(tested))
+	if failed > 0 {
+		return 1
 	}
-	return wrapped.Rules, nil
+	return 0
 }
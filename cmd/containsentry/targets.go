@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/katvixlab/contain-sentry/cmd/containsentry/config"
+	"github.com/katvixlab/contain-sentry/internal/compose"
+	"github.com/katvixlab/contain-sentry/internal/discovery"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/engine"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"github.com/katvixlab/contain-sentry/internal/report"
+	"github.com/katvixlab/contain-sentry/internal/ruleset"
+	"go.uber.org/zap"
+)
+
+// analysisTarget is one parsed artifact set ready to be driven by the engine.
+type analysisTarget struct {
+	name      string
+	artifacts []string
+	driver    engine.Driver
+}
+
+// loadTargets parses the configured targets. Only scan reports artifacts
+// that fail to load instead of failing the run; they fail the gate later.
+func loadTargets(ctx context.Context, cfg *config.ApplicationSettings) ([]analysisTarget, []report.ArtifactError, error) {
+	if cfg.Command == config.CommandScan {
+		return scanTargets(ctx, cfg)
+	}
+
+	targets := make([]analysisTarget, 0, len(cfg.Targets))
+	seen := map[string]struct{}{}
+	for _, name := range cfg.Targets {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		files := cfg.ComposeFiles
+		if name == discovery.TargetDockerfile {
+			files = []string{cfg.DockerfilePath}
+		}
+		target, err := newTarget(ctx, cfg, name, "", files)
+		if err != nil {
+			return nil, nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil, nil
+}
+
+// scanTargets discovers artifacts under the scan roots. Artifacts that fail
+// to load are returned as errors next to the loaded targets, so one broken
+// file does not hide the rest but is still reported.
+func scanTargets(ctx context.Context, cfg *config.ApplicationSettings) ([]analysisTarget, []report.ArtifactError, error) {
+	log := config.FromCtx(ctx)
+
+	roots := cfg.Paths
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	var targets []analysisTarget
+	var failures []report.ArtifactError
+	for _, root := range roots {
+		artifacts, err := discovery.Discover(root,
+			discovery.WithExclude(cfg.Exclude...),
+			discovery.WithGitignore(!cfg.NoGitignore),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan %q: %w", root, err)
+		}
+
+		// Locations are relative to the scan root; with several roots they
+		// are relative to the working directory to stay unambiguous.
+		baseDir := root
+		if len(roots) > 1 {
+			baseDir = "."
+		}
+		for _, artifact := range artifacts {
+			if len(cfg.Targets) > 0 && !slices.Contains(cfg.Targets, artifact.Target) {
+				continue
+			}
+			files := make([]string, 0, len(artifact.Files))
+			for _, file := range artifact.Files {
+				files = append(files, filepath.Join(root, filepath.FromSlash(file)))
+			}
+			target, err := newTarget(ctx, cfg, artifact.Target, baseDir, files)
+			if err != nil {
+				log.Warn("Failed to load artifact", zap.Error(err), zap.Strings("files", files))
+				failures = append(failures, report.ArtifactError{Target: artifact.Target, Files: files, Error: err.Error()})
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets, failures, nil
+}
+
+// newTarget parses the files of one artifact. A non-empty baseDir makes the
+// reported paths relative to it.
+func newTarget(ctx context.Context, cfg *config.ApplicationSettings, name string, baseDir string, files []string) (analysisTarget, error) {
+	switch name {
+	case discovery.TargetCompose:
+		var opts []compose.Option
+		if baseDir != "" {
+			opts = append(opts, compose.WithBaseDir(baseDir))
+		}
+		project, err := compose.NewProject(ctx, files, opts...)
+		if err != nil {
+			return analysisTarget{}, fmt.Errorf("create Compose project from %v: %w", files, err)
+		}
+		return analysisTarget{name: name, artifacts: project.Model.Files, driver: project.Driver()}, nil
+	case discovery.TargetDockerfile:
+		if len(files) != 1 {
+			return analysisTarget{}, fmt.Errorf("dockerfile target expects one file, got %v", files)
+		}
+		opts := []dockerfile.Option{dockerfile.WithBuildArgs(cfg.BuildArgs)}
+		if baseDir != "" {
+			opts = append(opts, dockerfile.WithBaseDir(baseDir))
+		}
+		df, err := dockerfile.NewDockerfile(ctx, files[0], opts...)
+		if err != nil {
+			return analysisTarget{}, fmt.Errorf("create Dockerfile from %q: %w", files[0], err)
+		}
+		return analysisTarget{name: name, artifacts: []string{df.File()}, driver: df.Driver()}, nil
+	default:
+		return analysisTarget{}, fmt.Errorf("unknown analysis target %q", name)
+	}
+}
+
+// targetNames lists the distinct targets in order of first appearance.
+func targetNames(targets []analysisTarget) []string {
+	var names []string
+	for _, target := range targets {
+		if !slices.Contains(names, target.name) {
+			names = append(names, target.name)
+		}
+	}
+	return names
+}
+
+func targetRunners() []engine.Runner {
+	return []engine.Runner{&dockerfile.DockerfileRunner{}, &compose.ComposeRunner{}}
+}
+
+// ruleVocabularies tells `rules lint` what the runner of each target evaluates.
+func ruleVocabularies() map[string]ruleset.Vocabulary {
+	return map[string]ruleset.Vocabulary{
+		"dockerfile": {
+			Subjects: dockerfile.Subjects(),
+			Kinds:    dockerfile.ExpressionKinds(),
+			Checks:   dockerfile.ConstraintChecks(),
+			Select:   dockerfile.KnownSelect,
+		},
+		"compose": {
+			Subjects: compose.Subjects(),
+			Kinds:    compose.ExpressionKinds(),
+			Select:   compose.KnownSelect,
+		},
+	}
+}
+
+func targetDrivers(targets []analysisTarget) []engine.Driver {
+	drivers := make([]engine.Driver, 0, len(targets))
+	for _, target := range targets {
+		drivers = append(drivers, target.driver)
+	}
+	return drivers
+}
+
+// sarifArtifacts maps each target to the artifact SARIF results fall back to
+// when a finding location does not name a file.
+func sarifArtifacts(targets []analysisTarget) map[string]string {
+	artifacts := make(map[string]string, len(targets))
+	for _, target := range targets {
+		if len(target.artifacts) > 0 {
+			artifacts[target.name] = target.artifacts[0]
+		}
+	}
+	return artifacts
+}
+
+// sourceBaseDir is the directory reported paths are relative to, so that
+// reports can read the analyzed files back. It mirrors scanTargets.
+func sourceBaseDir(cfg *config.ApplicationSettings) string {
+	if cfg.Command == config.CommandScan && len(cfg.Paths) == 1 {
+		return cfg.Paths[0]
+	}
+	return ""
+}
+
+// dropRepeatedFindings removes findings whose fingerprint an earlier
+// evaluation already reported. Scan analyzes a base Compose file both on its
+// own and under each variant file, so its findings would otherwise be counted
+// once per project.
+func dropRepeatedFindings(evaluations []engine.Evaluation) []entities.Finding {
+	var findings []entities.Finding
+	seen := map[string]struct{}{}
+	for i := range evaluations {
+		kept := evaluations[i].Findings[:0:0]
+		for _, finding := range evaluations[i].Findings {
+			fingerprint := report.Fingerprint(finding)
+			if _, ok := seen[fingerprint]; ok {
+				continue
+			}
+			seen[fingerprint] = struct{}{}
+			kept = append(kept, finding)
+		}
+		evaluations[i].Findings = kept
+		findings = append(findings, kept...)
+	}
+	return findings
+}
+
+// junitArtifacts pairs each target with its evaluation; EvaluateAll returns
+// one evaluation per driver in target order.
+func junitArtifacts(targets []analysisTarget, evaluations []engine.Evaluation) []report.JUnitArtifact {
+	artifacts := make([]report.JUnitArtifact, 0, len(evaluations))
+	for i, evaluation := range evaluations {
+		artifacts = append(artifacts, report.JUnitArtifact{
+			Files:      targets[i].artifacts,
+			Evaluation: evaluation,
+		})
+	}
+	return artifacts
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/cmd/containsentry/config"
+	"github.com/katvixlab/contain-sentry/internal/discovery"
+	"github.com/katvixlab/contain-sentry/internal/engine"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"go.uber.org/zap"
+)
+
+func TestScanTargetsReportsArtifactsThatFailToLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: [\n"), 0o644); err != nil {
+		t.Fatalf("write compose file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine:3.20\n"), 0o644); err != nil {
+		t.Fatalf("write Dockerfile: %v", err)
+	}
+
+	ctx := config.WithLogger(context.Background(), zap.NewNop())
+	targets, failures, err := scanTargets(ctx, &config.ApplicationSettings{Command: config.CommandScan, Paths: []string{dir}, NoGitignore: true})
+	if err != nil {
+		t.Fatalf("scanTargets() error = %v", err)
+	}
+	if len(targets) != 1 || targets[0].name != discovery.TargetDockerfile {
+		t.Fatalf("unexpected targets: %+v", targets)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("expected one load error, got %+v", failures)
+	}
+	failure := failures[0]
+	if failure.Target != discovery.TargetCompose || len(failure.Files) != 1 || filepath.Base(failure.Files[0]) != "compose.yaml" || failure.Error == "" {
+		t.Fatalf("unexpected load error: %+v", failure)
+	}
+}
+
+func TestScanReportsBaseComposeFindingsOnce(t *testing.T) {
+	base := "services:\n  app:\n    image: nginx:latest\n    privileged: true\n"
+	findings := func(files map[string]string) []entities.Finding {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+		ctx := config.WithLogger(context.Background(), zap.NewNop())
+		targets, failures, err := scanTargets(ctx, &config.ApplicationSettings{Command: config.CommandScan, Paths: []string{dir}, NoGitignore: true})
+		if err != nil || len(failures) != 0 {
+			t.Fatalf("scanTargets() = %v, %v", failures, err)
+		}
+		set, err := loadRules(nil, targetNames(targets))
+		if err != nil {
+			t.Fatalf("loadRules() error = %v", err)
+		}
+		evaluations, err := engine.New(set.Rules, targetRunners()...).EvaluateAll(ctx, targetDrivers(targets)...)
+		if err != nil {
+			t.Fatalf("EvaluateAll() error = %v", err)
+		}
+		return dropRepeatedFindings(evaluations)
+	}
+
+	alone := findings(map[string]string{"compose.yaml": base})
+	if len(alone) == 0 {
+		t.Fatalf("expected findings for the base file")
+	}
+	withVariant := findings(map[string]string{"compose.yaml": base, "docker-compose.prod.yml": "services:\n  app:\n    read_only: true\n"})
+	seen := map[string]bool{}
+	for _, finding := range withVariant {
+		if seen[finding.Fingerprint] {
+			t.Fatalf("%s reported twice", finding.ID)
+		}
+		seen[finding.Fingerprint] = true
+	}
+	for _, finding := range alone {
+		if !seen[finding.Fingerprint] {
+			t.Errorf("base finding %s missing with a variant file", finding.ID)
+		}
+	}
+	if len(withVariant) != len(alone) {
+		t.Fatalf("findings = %d for base plus variant, want %d as for the base alone", len(withVariant), len(alone))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	composetypes "github.com/compose-spec/compose-go/v2/types"
 	"github.com/katvixlab/contain-sentry/internal/compose/model"
 	"github.com/katvixlab/contain-sentry/internal/engine"
 	"github.com/katvixlab/contain-sentry/internal/entities"
@@ -125,39 +126,66 @@ func composeSteps(project *model.Project) []engine.Step {
 	}
 	sort.Strings(serviceNames)
 
+	// Only scan-root relative paths are stable enough to feed fingerprints.
+	file := ""
+	if project.BaseDir != "" && len(project.Files) > 0 {
+		file = project.Files[0]
+	}
+
 	steps := make([]engine.Step, 0, len(serviceNames)*(len(composeSubjects)+1))
 	for _, name := range serviceNames {
 		service := project.Services[name]
+		suppressions := append(append([]entities.Suppression{}, project.Suppressions...), service.Suppressions...)
 		for _, subject := range composeSubjects {
 			value, raw, present, path := subjectValue(service, subject)
 			steps = append(steps, engine.Step{
 				Target:   targetCompose,
 				Subject:  subject,
+				File:     file,
 				Path:     path,
 				Service:  name,
 				Raw:      raw,
 				Value:    value,
 				Present:  present,
-				Location: model.Location{Files: append([]string{}, project.Files...), ServiceName: name, Path: path},
+				Location: stepLocation(project, name, path),
 				Command:  &model.Command{Service: service, Path: path, Value: value},
+
+				Suppressions: suppressions,
 			})
 		}
 		steps = append(steps, engine.Step{
 			Target:   targetCompose,
 			Subject:  "eof",
+			File:     file,
 			Path:     "services." + name,
 			Service:  name,
 			Raw:      "services." + name,
 			Value:    service.Snapshot(),
 			Present:  true,
-			Location: model.Location{Files: append([]string{}, project.Files...), ServiceName: name, Path: "services." + name},
+			Location: stepLocation(project, name, "services."+name),
 			Command:  &model.Command{Service: service, Path: "services." + name, Value: service.Snapshot()},
+
+			Suppressions: suppressions,
 		})
 	}
 
 	return steps
 }
 
+func stepLocation(project *model.Project, service string, path string) model.Location {
+	location := model.Location{Files: append([]string{}, project.Files...), ServiceName: service, Path: path}
+	if position, ok := project.Locate(path); ok {
+		location.File = position.File
+		location.Line = position.Line
+		location.Column = position.Column
+	}
+	// Provenance only tells something when overrides are in play.
+	if field, ok := strings.CutPrefix(path, "services."+service+"."); ok && len(project.Files) > 1 {
+		location.Origin = project.Services[service].Origin(field)
+	}
+	return location
+}
+
 func subjectValue(service *model.Service, subject string) (any, string, bool, string) {
 	base := "services." + service.Name
 	switch subject {
@@ -234,96 +262,193 @@ func composeSelect(project *model.Project, service *model.Service, selectPath st
 		}
 	}
 
-	switch normalized {
-	case "service":
+	if field, ok := cutSelectPrefix(selectPath, "service.provenance."); ok {
+		files := service.Provenance[provenanceField(field)]
+		return files, len(files) > 0
+	}
+	if field, ok := cutSelectPrefix(selectPath, "service.origin."); ok {
+		files := service.Provenance[provenanceField(field)]
+		if len(files) == 0 {
+			return "", false
+		}
+		return files[len(files)-1], true
+	}
+
+	selector, ok := composeSelectors[normalized]
+	if !ok {
+		return nil, false
+	}
+	return selector(project, service)
+}
+
+// composeSelectors resolve the select paths of field expressions. Service
+// selectors are only called with a service.
+var composeSelectors = map[string]func(project *model.Project, service *model.Service) (any, bool){
+	"service": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Snapshot(), true
-	case "service.name":
+	},
+	"service.name": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Name, true
-	case "service.user":
+	},
+	"service.user": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.User, service.HasField("user")
-	case "service.userns_mode":
+	},
+	"service.userns_mode": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.UserNSMode, service.HasField("userns_mode")
-	case "service.read_only":
+	},
+	"service.read_only": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.ReadOnly, service.HasField("read_only")
-	case "service.privileged":
+	},
+	"service.privileged": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Privileged, service.HasField("privileged")
-	case "service.cap_add":
+	},
+	"service.cap_add": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.CapAdd, service.HasField("cap_add")
-	case "service.cap_drop":
+	},
+	"service.cap_drop": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.CapDrop, service.HasField("cap_drop")
-	case "service.security_opt":
+	},
+	"service.security_opt": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.SecurityOpt, service.HasField("security_opt")
-	case "service.network_mode":
+	},
+	"service.network_mode": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.NetworkMode, service.HasField("network_mode")
-	case "service.networks":
+	},
+	"service.networks": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Networks, service.HasField("networks")
-	case "service.pid":
+	},
+	"service.pid": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Pid, service.HasField("pid")
-	case "service.ipc":
+	},
+	"service.ipc": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Ipc, service.HasField("ipc")
-	case "service.devices":
+	},
+	"service.devices": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Devices, service.HasField("devices")
-	case "service.environment":
+	},
+	"service.environment": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Environment, service.HasField("environment")
-	case "service.secrets":
+	},
+	"service.secrets": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Secrets, service.HasField("secrets")
-	case "service.healthcheck":
+	},
+	"service.healthcheck": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.HealthCheck, service.HasField("healthcheck")
-	case "service.depends_on":
+	},
+	"service.depends_on": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.DependsOn, service.HasField("depends_on")
-	case "service.restart":
+	},
+	"service.restart": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Restart, service.HasField("restart")
-	case "service.profiles":
+	},
+	"service.profiles": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Profiles, service.HasField("profiles")
-	case "service.ports":
+	},
+	"service.ports": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Ports, service.HasField("ports")
-	case "service.volumes":
+	},
+	"service.volumes": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Volumes, service.HasField("volumes")
-	case "service.image":
+	},
+	"service.image": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Image, service.HasField("image")
-	case "service.build":
+	},
+	"service.build": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Build, service.HasField("build")
-	case "service.logging":
+	},
+	"service.logging": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Logging, service.HasField("logging")
-	case "service.init":
+	},
+	"service.init": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.Init, service.HasField("init")
-	case "service.stop_grace_period":
+	},
+	"service.stop_grace_period": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.StopGracePeriod, service.HasField("stop_grace_period")
-	case "service.stop_signal":
+	},
+	"service.stop_signal": func(_ *model.Project, service *model.Service) (any, bool) {
 		return service.Config.StopSignal, service.HasField("stop_signal")
-	case "service.resource_limits":
+	},
+	"service.resource_limits": func(_ *model.Project, service *model.Service) (any, bool) {
 		if service.Config.Deploy == nil {
 			return nil, service.HasField("resource_limits")
 		}
 		return service.Config.Deploy.Resources, service.HasField("resource_limits")
-	case "compose.project_name":
+	},
+	"service.memory_limit": func(_ *model.Project, service *model.Service) (any, bool) {
+		if limits := resourceLimits(service); limits != nil && limits.MemoryBytes != 0 {
+			return limits.MemoryBytes, true
+		}
+		return service.Config.MemLimit, service.HasField("mem_limit")
+	},
+	"service.cpu_limit": func(_ *model.Project, service *model.Service) (any, bool) {
+		if limits := resourceLimits(service); limits != nil && limits.NanoCPUs != 0 {
+			return limits.NanoCPUs, true
+		}
+		return service.Config.CPUS, service.HasField("cpus")
+	},
+	"service.pids_limit": func(_ *model.Project, service *model.Service) (any, bool) {
+		if limits := resourceLimits(service); limits != nil && limits.Pids != 0 {
+			return limits.Pids, true
+		}
+		return service.Config.PidsLimit, service.HasField("pids_limit")
+	},
+	"compose.project_name": func(project *model.Project, _ *model.Service) (any, bool) {
 		if project == nil {
 			return "", false
 		}
 		return project.Name, project.TopLevel["name"]
-	case "compose.secrets":
+	},
+	"compose.secrets": func(project *model.Project, _ *model.Service) (any, bool) {
 		if project == nil {
 			return nil, false
 		}
 		return project.Secrets, project.TopLevel["secrets"]
-	case "compose.networks":
+	},
+	"compose.networks": func(project *model.Project, _ *model.Service) (any, bool) {
 		if project == nil {
 			return nil, false
 		}
 		return project.Networks, project.TopLevel["networks"]
-	case "compose.volumes":
+	},
+	"compose.volumes": func(project *model.Project, _ *model.Service) (any, bool) {
 		if project == nil {
 			return nil, false
 		}
 		return project.Volumes, project.TopLevel["volumes"]
-	case "compose.profiles":
+	},
+	"compose.profiles": func(project *model.Project, _ *model.Service) (any, bool) {
 		if project == nil {
 			return nil, false
 		}
 		return project.Profiles, project.TopLevel["profiles"] || len(project.Profiles) > 0
-	default:
-		return nil, false
+	},
+}
+
+// resourceLimits are the deploy.resources.limits of a service, which take
+// precedence over the legacy mem_limit, cpus and pids_limit keys.
+func resourceLimits(service *model.Service) *composetypes.Resource {
+	if service.Config.Deploy == nil {
+		return nil
 	}
+	return service.Config.Deploy.Resources.Limits
+}
+
+// cutSelectPrefix strips a case-insensitive prefix but keeps the case of the
+// remainder, which may name environment variables or labels.
+func cutSelectPrefix(selectPath string, prefix string) (string, bool) {
+	selectPath = strings.TrimSpace(selectPath)
+	if len(selectPath) <= len(prefix) || !strings.EqualFold(selectPath[:len(prefix)], prefix) {
+		return "", false
+	}
+	return selectPath[len(prefix):], true
+}
+
+// provenanceField maps subject names onto the YAML path they are read from.
+func provenanceField(field string) string {
+	if field == "resource_limits" {
+		return "deploy.resources"
+	}
+	return field
 }
 
 func stringifyComposeValue(value any) string {
@@ -345,3 +470,36 @@ func stringifyComposeValue(value any) string {
 	}
 	return string(payload)
 }
+
+// Subjects lists the step subjects compose rules can match.
+func Subjects() []string {
+	return append(append([]string{}, composeSubjects...), "eof")
+}
+
+// ExpressionKinds lists the expression kinds the compose runner evaluates.
+func ExpressionKinds() []string {
+	return []string{"field"}
+}
+
+// Selects lists the select paths of field expressions, besides
+// service.provenance.<field> and service.origin.<field>.
+func Selects() []string {
+	selects := make([]string, 0, len(composeSelectors))
+	for selectPath := range composeSelectors {
+		selects = append(selects, selectPath)
+	}
+	sort.Strings(selects)
+	return selects
+}
+
+// KnownSelect reports whether a field expression select path resolves to a
+// compose value.
+func KnownSelect(selectPath string) bool {
+	for _, prefix := range []string{"service.provenance.", "service.origin."} {
+		if _, ok := cutSelectPrefix(selectPath, prefix); ok {
+			return true
+		}
+	}
+	_, ok := composeSelectors[strings.ToLower(strings.TrimSpace(selectPath))]
+	return ok
+}
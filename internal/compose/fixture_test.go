@@ -8,6 +8,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
 	"github.com/katvixlab/contain-sentry/internal/entities"
 )
 
@@ -39,6 +40,7 @@ func TestComposeFixtures(t *testing.T) {
 		{name: "service without resource limits", file: "no-resource-limits.compose.yaml", want: []string{"CP023"}},
 		{name: "service without stop controls", file: "no-stop-controls.compose.yaml", want: []string{"CP031"}},
 		{name: "secure service", file: "secure-service.compose.yaml", want: nil},
+		{name: "x-containsentry waivers", file: "waived-public-port.compose.yaml", want: nil},
 	}
 
 	for _, tt := range tests {
@@ -68,6 +70,51 @@ func TestComposeFixtures(t *testing.T) {
 	}
 }
 
+func TestComposeExtensionWaiversAreRecorded(t *testing.T) {
+	project, err := NewProject(context.Background(), []string{filepath.Join("testdata", "waived-public-port.compose.yaml")})
+	if err != nil {
+		t.Fatalf("NewProject() error = %v", err)
+	}
+	findings, err := project.Validate(context.Background(), loadComposeRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	scopes := map[string]string{}
+	for _, finding := range findings {
+		if finding.Suppression == nil {
+			t.Fatalf("unexpected active finding %s", finding.ID)
+		}
+		if finding.Suppression.Source != "x-containsentry" || finding.Suppression.Reason == "" {
+			t.Fatalf("unexpected suppression for %s: %+v", finding.ID, finding.Suppression)
+		}
+		scopes[finding.ID] = finding.Suppression.Scope
+	}
+	if scopes["CP024"] != "service" || scopes["CP031"] != "project" || len(scopes) != 2 {
+		t.Fatalf("unexpected suppressed findings: %v", scopes)
+	}
+}
+
+func TestComposeLocationsUseBaseDirRelativePath(t *testing.T) {
+	project, err := NewProject(context.Background(), []string{filepath.Join("testdata", "public-port.compose.yaml")}, WithBaseDir("testdata"))
+	if err != nil {
+		t.Fatalf("NewProject() error = %v", err)
+	}
+	findings, err := project.Validate(context.Background(), loadComposeRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(findings) == 0 {
+		t.Fatalf("findings are empty")
+	}
+	for _, finding := range findings {
+		location, ok := finding.Location.(model.Location)
+		if !ok || len(location.Files) != 1 || location.Files[0] != "public-port.compose.yaml" {
+			t.Fatalf("%s location = %#v, want file relative to testdata", finding.ID, finding.Location)
+		}
+	}
+}
+
 func loadComposeRules(t *testing.T) []entities.BaseRule {
 	t.Helper()
 	var rules []entities.BaseRule
@@ -89,7 +136,66 @@ func readJSON(t *testing.T, path string, target any) {
 func findingIDs(findings []entities.Finding) []string {
 	ids := make([]string, 0, len(findings))
 	for _, finding := range findings {
+		if finding.Suppression != nil {
+			continue
+		}
 		ids = append(ids, finding.ID)
 	}
 	return ids
 }
+
+func TestComposeLocationsPointAtLastFileToSetValue(t *testing.T) {
+	dir := filepath.Join("testdata", "overrides")
+	project, err := NewProject(context.Background(), []string{
+		filepath.Join(dir, "compose.yaml"),
+		filepath.Join(dir, "compose.prod.yaml"),
+	}, WithBaseDir(dir))
+	if err != nil {
+		t.Fatalf("NewProject() error = %v", err)
+	}
+	findings, err := project.Validate(context.Background(), loadComposeRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	var privileged *model.Location
+	for _, finding := range findings {
+		if finding.ID == "CP003" {
+			location := finding.Location.(model.Location)
+			privileged = &location
+		}
+	}
+	if privileged == nil {
+		t.Fatalf("CP003 not reported; got %v", findingIDs(findings))
+	}
+	if privileged.File != "compose.prod.yaml" || privileged.Line != 3 || privileged.Column != 5 {
+		t.Fatalf("CP003 location = %+v, want compose.prod.yaml:3:5", *privileged)
+	}
+	if privileged.Origin != "set in compose.prod.yaml, overriding compose.yaml" {
+		t.Fatalf("CP003 origin = %q", privileged.Origin)
+	}
+
+	api := project.Model.Services["api"]
+	if got := api.Provenance["ports"]; len(got) != 2 || got[1] != "compose.prod.yaml" {
+		t.Fatalf("ports provenance = %v, want both files", got)
+	}
+	if got := api.Origin("read_only"); got != "set in compose.yaml" {
+		t.Fatalf("read_only origin = %q, want base file only", got)
+	}
+
+	tests := []struct {
+		path string
+		want model.Position
+	}{
+		{path: "services.api.image", want: model.Position{File: "compose.yaml", Line: 8, Column: 5}},
+		{path: "services.api.read_only", want: model.Position{File: "compose.yaml", Line: 2, Column: 3}},
+		{path: "services.api.healthcheck", want: model.Position{File: "compose.prod.yaml", Line: 2, Column: 3}},
+		{path: "services.api.deploy.resources", want: model.Position{File: "compose.prod.yaml", Line: 2, Column: 3}},
+	}
+	for _, tt := range tests {
+		got, ok := project.Model.Locate(tt.path)
+		if !ok || got != tt.want {
+			t.Fatalf("Locate(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,17 @@
 package model
 
-import composetypes "github.com/compose-spec/compose-go/v2/types"
+import (
+	"path/filepath"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
 
 type Project struct {
 	Name     string
 	Files    []string
+	BaseDir  string
 	Services map[string]*Service
 	Secrets  composetypes.Secrets
 	Networks composetypes.Networks
@@ -12,6 +19,10 @@ type Project struct {
 	Profiles []string
 	Raw      map[string]any
 	TopLevel map[string]bool
+	// Positions maps dotted paths to the key that last set them.
+	Positions map[string]Position
+
+	Suppressions []entities.Suppression
 }
 
 type Service struct {
@@ -22,12 +33,48 @@ type Service struct {
 	Disabled  bool
 	Project   *Project
 	SourceRaw any
+	// Provenance lists, per field path below the service, the files that
+	// set it in merge order; the last one wins.
+	Provenance map[string][]string
+
+	Suppressions []entities.Suppression
 }
 
+// Location identifies a Compose finding. File, Line and Column (both 1-based)
+// point at the key for Path, or at its closest ancestor when the key is absent.
 type Location struct {
 	Files       []string `json:"files,omitempty"`
 	ServiceName string   `json:"service_name,omitempty"`
 	Path        string   `json:"path,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	Column      int      `json:"column,omitempty"`
+	Origin      string   `json:"origin,omitempty"`
+}
+
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// Locate returns the position of path, falling back to its nearest recorded
+// parent.
+func (p *Project) Locate(path string) (Position, bool) {
+	if p == nil {
+		return Position{}, false
+	}
+	for path != "" {
+		if position, ok := p.Positions[path]; ok {
+			return position, true
+		}
+		index := strings.LastIndex(path, ".")
+		if index < 0 {
+			break
+		}
+		path = path[:index]
+	}
+	return Position{}, false
 }
 
 type Command struct {
@@ -43,6 +90,27 @@ func (s *Service) HasField(name string) bool {
 	return s.Present[name]
 }
 
+// Origin describes which files set field, e.g. "set in compose.prod.yaml,
+// overriding compose.yaml". Files of a project share a directory, so base
+// names are enough.
+func (s *Service) Origin(field string) string {
+	if s == nil {
+		return ""
+	}
+	files := make([]string, 0, len(s.Provenance[field]))
+	for _, file := range s.Provenance[field] {
+		files = append(files, filepath.Base(file))
+	}
+	switch len(files) {
+	case 0:
+		return ""
+	case 1:
+		return "set in " + files[0]
+	default:
+		return "set in " + files[len(files)-1] + ", overriding " + strings.Join(files[:len(files)-1], ", ")
+	}
+}
+
 func (s *Service) Snapshot() map[string]any {
 	if s == nil {
 		return nil
@@ -79,6 +147,7 @@ func (s *Service) Snapshot() map[string]any {
 		"resource_limits":   resourceLimitsSnapshot(s.Config.Deploy),
 		"disabled":          s.Disabled,
 		"present":           s.Present,
+		"provenance":        s.Provenance,
 		"raw":               s.Raw,
 		"top_secrets":       s.Project.Secrets,
 		"top_networks":      s.Project.Networks,
@@ -0,0 +1,106 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"go.yaml.in/yaml/v4"
+)
+
+// sourceMap records, per dotted path such as services.app.privileged, the key
+// that last set it and every file that set it, in merge order.
+type sourceMap struct {
+	positions map[string]model.Position
+	files     map[string][]string
+}
+
+func (m sourceMap) record(path string, position model.Position) {
+	m.positions[path] = position
+	files := m.files[path]
+	if len(files) == 0 || files[len(files)-1] != position.File {
+		m.files[path] = append(files, position.File)
+	}
+}
+
+// loadSources reads the files in merge order, so an override file replaces
+// the position recorded for the base file. display holds the paths reported
+// for files.
+func loadSources(files []string, display []string) (sourceMap, error) {
+	sources := sourceMap{positions: map[string]model.Position{}, files: map[string][]string{}}
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return sourceMap{}, fmt.Errorf("read compose file %q: %w", file, err)
+		}
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return sourceMap{}, fmt.Errorf("parse compose file %q: %w", file, err)
+		}
+		name := file
+		if i < len(display) {
+			name = display[i]
+		}
+		for _, root := range document.Content {
+			recordPositions(root, "", name, sources)
+		}
+	}
+	return sources, nil
+}
+
+// applyProvenance annotates every service with the files that set each of
+// its fields, keyed by the path below services.<name>.
+func applyProvenance(project *model.Project, sources sourceMap) {
+	for name, service := range project.Services {
+		prefix := "services." + name + "."
+		service.Provenance = map[string][]string{}
+		for path, files := range sources.files {
+			if field, ok := strings.CutPrefix(path, prefix); ok {
+				service.Provenance[field] = files
+			}
+		}
+	}
+}
+
+// recordPositions walks mappings only: Compose merges sequences by appending,
+// so an item index does not identify the same entry across files. Keys pulled
+// in through a `<<` merge point at the anchored mapping unless set locally.
+func recordPositions(node *yaml.Node, prefix string, file string, sources sourceMap) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key, value := node.Content[i], node.Content[i+1]; key.Value == "<<" {
+			for _, merged := range mergeSources(value) {
+				recordPositions(merged, prefix, file, sources)
+			}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			continue
+		}
+		path := joinPath(prefix, key.Value)
+		sources.record(path, model.Position{File: file, Line: key.Line, Column: key.Column})
+		recordPositions(value, path, file, sources)
+	}
+}
+
+func mergeSources(value *yaml.Node) []*yaml.Node {
+	if value.Kind == yaml.SequenceNode {
+		return value.Content
+	}
+	return []*yaml.Node{value}
+}
+
+func joinPath(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/compose-spec/compose-go/v2/loader"
 	composetypes "github.com/compose-spec/compose-go/v2/types"
@@ -20,7 +21,25 @@ type Project struct {
 	Model *model.Project
 }
 
-func NewProject(ctx context.Context, files []string) (*Project, error) {
+type Option func(*projectOptions)
+
+type projectOptions struct {
+	baseDir string
+}
+
+// WithBaseDir reports the Compose file paths relative to dir in locations.
+func WithBaseDir(dir string) Option {
+	return func(x *projectOptions) {
+		x.baseDir = dir
+	}
+}
+
+func NewProject(ctx context.Context, files []string, opts ...Option) (*Project, error) {
+	options := projectOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	details, normalizedFiles, err := composeConfigDetails(files)
 	if err != nil {
 		return nil, err
@@ -41,15 +60,47 @@ func NewProject(ctx context.Context, files []string) (*Project, error) {
 		return nil, fmt.Errorf("load compose model: %w", err)
 	}
 
-	return &Project{
-		Model: buildProjectModel(project, rawModel, normalizedFiles),
-	}, nil
+	display := displayFiles(options.baseDir, normalizedFiles)
+	pm := buildProjectModel(project, rawModel, display)
+	pm.BaseDir = options.baseDir
+	sources, err := loadSources(normalizedFiles, display)
+	if err != nil {
+		return nil, err
+	}
+	pm.Positions = sources.positions
+	applyProvenance(pm, sources)
+	return &Project{Model: pm}, nil
+}
+
+// displayFiles keeps absolute paths unless a base directory is configured.
+func displayFiles(baseDir string, files []string) []string {
+	if baseDir == "" {
+		return files
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return files
+	}
+	display := make([]string, 0, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(absBase, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			display = append(display, file)
+			continue
+		}
+		display = append(display, filepath.ToSlash(rel))
+	}
+	return display
+}
+
+// Driver returns a fresh driver over the normalized project model.
+func (p *Project) Driver() engine.Driver {
+	return NewComposeDriver(p.Model)
 }
 
 func (p *Project) Validate(ctx context.Context, rules []entities.BaseRule) ([]entities.Finding, error) {
-	driver := NewComposeDriver(p.Model)
 	eng := engine.New(rules, &ComposeRunner{})
-	return eng.Run(ctx, driver)
+	return eng.Run(ctx, p.Driver())
 }
 
 func composeConfigDetails(files []string) (composetypes.ConfigDetails, []string, error) {
@@ -113,6 +164,8 @@ func buildProjectModel(project *composetypes.Project, raw map[string]any, files
 		Volumes:  project.Volumes,
 		Raw:      raw,
 		TopLevel: map[string]bool{},
+
+		Suppressions: extensionSuppressions(raw, entities.SuppressionScopeProject),
 	}
 
 	for _, key := range []string{"services", "secrets", "networks", "volumes", "profiles", "name"} {
@@ -143,6 +196,8 @@ func buildProjectModel(project *composetypes.Project, raw map[string]any, files
 			Present:  servicePresence(serviceRaw),
 			Disabled: disabled,
 			Project:  pm,
+
+			Suppressions: extensionSuppressions(serviceRaw, entities.SuppressionScopeService),
 		}
 		pm.Services[name] = svc
 		for _, profile := range serviceCfg.Profiles {
@@ -178,7 +233,7 @@ func servicePresence(raw map[string]any) map[string]bool {
 		"userns_mode", "cap_add", "cap_drop", "security_opt", "network_mode",
 		"networks", "pid", "ipc", "devices", "ports", "volumes", "environment",
 		"secrets", "healthcheck", "depends_on", "restart", "logging", "init",
-		"stop_grace_period", "stop_signal", "deploy",
+		"stop_grace_period", "stop_signal", "deploy", "mem_limit", "cpus", "pids_limit",
 	} {
 		_, ok := raw[field]
 		present[field] = ok
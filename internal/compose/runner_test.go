@@ -2,6 +2,10 @@ package compose
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/compose-spec/compose-go/v2/types"
@@ -109,3 +113,129 @@ func TestComposeServiceSummaryStepUsesShortRaw(t *testing.T) {
 	}
 	t.Fatalf("service step not found")
 }
+
+func TestComposeRunnerSelectsProvenance(t *testing.T) {
+	project := &model.Project{
+		Files:    []string{"compose.yaml", "compose.prod.yaml"},
+		Services: map[string]*model.Service{},
+	}
+	service := &model.Service{
+		Name:    "app",
+		Config:  types.ServiceConfig{Name: "app", Privileged: true},
+		Present: map[string]bool{"privileged": true, "service": true, "name": true},
+		Project: project,
+		Provenance: map[string][]string{
+			"privileged": {"compose.yaml", "compose.prod.yaml"},
+			"image":      {"compose.yaml"},
+		},
+	}
+	project.Services["app"] = service
+
+	rule := entities.BaseRule{
+		Target:   "compose",
+		Phase:    "post",
+		Subject:  "privileged",
+		Metadata: &entities.Metadata{ID: "PROD001"},
+		Expression: &entities.ExpressionField{
+			ExprKind: "field",
+			Select:   "service.privileged",
+			Expr: &entities.FieldExprNode{Op: "all", Args: []*entities.FieldExprNode{
+				{Op: "eq", Value: true},
+				{Op: "field", Select: "service.origin.privileged", Arg: &entities.FieldExprNode{Op: "eq", Value: "compose.prod.yaml"}},
+			}},
+		},
+	}
+	step := engine.Step{Target: "compose", Subject: "privileged", Service: "app"}
+	if findings := (&ComposeRunner{}).Eval(context.Background(), project, rule, step); len(findings) != 1 {
+		t.Fatalf("Eval() findings = %d, want 1", len(findings))
+	}
+
+	service.Provenance["privileged"] = []string{"compose.yaml"}
+	if findings := (&ComposeRunner{}).Eval(context.Background(), project, rule, step); len(findings) != 0 {
+		t.Fatalf("Eval() findings = %d, want 0 for a base-file value", len(findings))
+	}
+
+	value, present := composeSelect(project, service, "service.provenance.image")
+	if files, ok := value.([]string); !present || !ok || len(files) != 1 || files[0] != "compose.yaml" {
+		t.Fatalf("service.provenance.image = %v, %v", value, present)
+	}
+	if _, present := composeSelect(project, service, "service.origin.user"); present {
+		t.Fatalf("service.origin.user present, want absent")
+	}
+}
+
+func TestComposeSelectsResourceLimits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "compose.yaml")
+	content := `services:
+  api:
+    image: api:1.0
+    stop_grace_period: 2s
+    deploy:
+      resources:
+        limits:
+          memory: 3g
+          cpus: "1.5"
+          pids: 200
+  worker:
+    image: worker:1.0
+    mem_limit: 512m
+  cache:
+    image: redis:7
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	project, err := NewProject(context.Background(), []string{path}, WithBaseDir(dir))
+	if err != nil {
+		t.Fatalf("NewProject() error = %v", err)
+	}
+
+	rule := entities.BaseRule{
+		Target:   "compose",
+		Phase:    "post",
+		Subject:  "service",
+		Metadata: &entities.Metadata{ID: "MEM001"},
+		Expression: &entities.ExpressionField{
+			ExprKind: "field",
+			Select:   "service.memory_limit",
+			Expr: &entities.FieldExprNode{Op: "any", Args: []*entities.FieldExprNode{
+				{Op: "gt", Value: "2g"},
+				{Op: "not", Arg: &entities.FieldExprNode{Op: "exists"}},
+			}},
+		},
+	}
+	findings, err := project.Validate(context.Background(), []entities.BaseRule{rule})
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	var services []string
+	for _, finding := range findings {
+		services = append(services, finding.Location.(model.Location).ServiceName)
+	}
+	sort.Strings(services)
+	if strings.Join(services, ",") != "api,cache" {
+		t.Fatalf("findings for services %v, want api and cache", services)
+	}
+
+	api := project.Model.Services["api"]
+	checks := []struct {
+		path string
+		op   string
+		want any
+	}{
+		{"service.cpu_limit", "gt", 1},
+		{"service.pids_limit", "lte", 200},
+		{"service.stop_grace_period", "lt", "5s"},
+	}
+	for _, check := range checks {
+		value, present := composeSelect(project.Model, api, check.path)
+		node := &entities.FieldExprNode{Op: check.op, Value: check.want}
+		if !present || !node.Eval(entities.FieldInput{Value: value, Present: present}) {
+			t.Errorf("%s = %v (present %v), want %s %v", check.path, value, present, check.op, check.want)
+		}
+	}
+	if value, present := composeSelect(project.Model, project.Model.Services["worker"], "service.memory_limit"); !present || !(&entities.FieldExprNode{Op: "eq", Value: 512 << 20}).Eval(entities.FieldInput{Value: value}) {
+		t.Fatalf("worker memory_limit = %v (present %v), want mem_limit", value, present)
+	}
+}
@@ -0,0 +1,74 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const extensionKey = "x-containsentry"
+
+// extensionSuppressions reads waivers from the x-containsentry extension. The
+// extension is either a single entry or a list of entries:
+//
+//	x-containsentry:
+//	  ignore: [CP024]
+//	  reason: "exposed through the ingress only"
+func extensionSuppressions(raw map[string]any, scope string) []entities.Suppression {
+	if raw == nil {
+		return nil
+	}
+	value, ok := raw[extensionKey]
+	if !ok {
+		return nil
+	}
+
+	var entries []map[string]any
+	switch typed := value.(type) {
+	case map[string]any:
+		entries = append(entries, typed)
+	case []any:
+		for _, item := range typed {
+			if entry, ok := item.(map[string]any); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	suppressions := make([]entities.Suppression, 0, len(entries))
+	for _, entry := range entries {
+		rules := extensionRules(entry["ignore"])
+		if len(rules) == 0 {
+			continue
+		}
+		reason, _ := entry["reason"].(string)
+		suppressions = append(suppressions, entities.Suppression{
+			Rules:  rules,
+			Reason: strings.TrimSpace(reason),
+			Scope:  scope,
+			Source: extensionKey,
+		})
+	}
+	return suppressions
+}
+
+func extensionRules(value any) []string {
+	switch typed := value.(type) {
+	case string:
+		return strings.FieldsFunc(typed, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	case []any:
+		rules := make([]string, 0, len(typed))
+		for _, item := range typed {
+			rule := strings.TrimSpace(fmt.Sprintf("%v", item))
+			if rule != "" {
+				rules = append(rules, rule)
+			}
+		}
+		return rules
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	TargetDockerfile = "dockerfile"
+	TargetCompose    = "compose"
+)
+
+// Artifact is a unit of analysis found under the scan root. Compose projects
+// may span several files: the base file first, then its overrides.
+type Artifact struct {
+	Target string
+	// Files are slash-separated paths relative to the scan root.
+	Files []string
+}
+
+type Option func(*options)
+
+type options struct {
+	exclude   []string
+	gitignore bool
+}
+
+// WithExclude skips paths matching any of the patterns. Patterns use the
+// .gitignore syntax, e.g. vendor/ or **/testdata/**.
+func WithExclude(patterns ...string) Option {
+	return func(x *options) {
+		x.exclude = append(x.exclude, patterns...)
+	}
+}
+
+// WithGitignore controls whether .gitignore files are honored; they are by
+// default.
+func WithGitignore(enabled bool) Option {
+	return func(x *options) {
+		x.gitignore = enabled
+	}
+}
+
+// Discover walks root and returns the Dockerfiles and Compose projects in
+// lexical path order.
+func Discover(root string, opts ...Option) ([]Artifact, error) {
+	options := options{gitignore: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	exclude := &matcher{}
+	excludeFile := &ignoreFile{base: "."}
+	for _, item := range options.exclude {
+		if p, ok := compilePattern(item); ok {
+			excludeFile.patterns = append(excludeFile.patterns, p)
+		}
+	}
+	exclude.add(excludeFile)
+
+	ignore := &matcher{}
+	var artifacts []Artifact
+	var composeDirs []string
+	composeFiles := map[string][]string{}
+
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if rel != "." && (entry.Name() == ".git" || ignore.ignored(rel, true) || exclude.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			if options.gitignore {
+				gitignore := filepath.Join(filePath, ".gitignore")
+				if file, err := loadIgnoreFile(gitignore, rel); err == nil {
+					ignore.add(file)
+				} else if !errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("read %s: %w", gitignore, err)
+				}
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || ignore.ignored(rel, false) || exclude.ignored(rel, false) {
+			return nil
+		}
+
+		name := entry.Name()
+		switch {
+		case IsDockerfile(name):
+			artifacts = append(artifacts, Artifact{Target: TargetDockerfile, Files: []string{rel}})
+		case IsComposeFile(name) || isComposeOverride(name):
+			dir := path.Dir(rel)
+			if _, ok := composeFiles[dir]; !ok {
+				composeDirs = append(composeDirs, dir)
+			}
+			composeFiles[dir] = append(composeFiles[dir], name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range composeDirs {
+		for _, files := range composeProjects(composeFiles[dir]) {
+			for i, file := range files {
+				files[i] = path.Join(dir, file)
+			}
+			artifacts = append(artifacts, Artifact{Target: TargetCompose, Files: files})
+		}
+	}
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return artifacts[i].Files[0] < artifacts[j].Files[0]
+	})
+	return artifacts, nil
+}
+
+// IsDockerfile matches Dockerfile, Containerfile and *.Dockerfile.
+func IsDockerfile(name string) bool {
+	return name == "Dockerfile" || name == "Containerfile" || strings.HasSuffix(name, ".Dockerfile")
+}
+
+// IsComposeFile matches compose.y*ml and docker-compose*.y*ml.
+func IsComposeFile(name string) bool {
+	for _, glob := range []string{"compose.y*ml", "docker-compose*.y*ml"} {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// baseComposeFiles are listed in the order docker compose prefers them.
+var baseComposeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+func isComposeOverride(name string) bool {
+	return composeOverrideOf(name) != ""
+}
+
+// composeOverrideOf returns the base file prefix (compose or docker-compose)
+// of an override file such as compose.override.yaml.
+func composeOverrideOf(name string) string {
+	for _, prefix := range []string{"compose", "docker-compose"} {
+		for _, ext := range []string{".yaml", ".yml"} {
+			if name == prefix+".override"+ext {
+				return prefix
+			}
+		}
+	}
+	return ""
+}
+
+// composeProjects groups the Compose files of one directory the way docker
+// compose would load them: every base file is merged with its override file,
+// and variant files such as docker-compose.prod.yml are layered on top of the
+// preferred base file.
+func composeProjects(names []string) [][]string {
+	present := map[string]bool{}
+	for _, name := range names {
+		present[name] = true
+	}
+
+	overridesOf := func(base string) []string {
+		prefix := strings.TrimSuffix(strings.TrimSuffix(base, ".yaml"), ".yml")
+		var overrides []string
+		for _, name := range names {
+			if composeOverrideOf(name) == prefix {
+				overrides = append(overrides, name)
+			}
+		}
+		return overrides
+	}
+
+	primary := ""
+	for _, base := range baseComposeFiles {
+		if present[base] {
+			primary = base
+			break
+		}
+	}
+
+	var projects [][]string
+	attached := map[string]bool{}
+	for _, base := range baseComposeFiles {
+		if !present[base] {
+			continue
+		}
+		project := append([]string{base}, overridesOf(base)...)
+		for _, name := range project[1:] {
+			attached[name] = true
+		}
+		projects = append(projects, project)
+	}
+
+	for _, name := range names {
+		if slices.Contains(baseComposeFiles, name) || attached[name] {
+			continue
+		}
+		if isComposeOverride(name) || primary == "" {
+			projects = append(projects, []string{name})
+			continue
+		}
+		projects = append(projects, []string{primary, name})
+	}
+	return projects
+}
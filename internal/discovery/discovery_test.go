@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestDiscoverFindsDockerfilesAndComposeProjects(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"Dockerfile":                          "FROM alpine\n",
+		"compose.yaml":                        "services: {}\n",
+		"compose.override.yaml":               "services: {}\n",
+		"docker-compose.prod.yml":             "services: {}\n",
+		"api/Containerfile":                   "FROM alpine\n",
+		"api/worker.Dockerfile":               "FROM alpine\n",
+		"api/README.md":                       "docs\n",
+		"deploy/docker-compose.yml":           "services: {}\n",
+		"deploy/docker-compose.override.yml":  "services: {}\n",
+		"legacy/docker-compose.staging.yaml":  "services: {}\n",
+		"notes/Dockerfile.md":                 "docs\n",
+		"notes/compose.override.example.yaml": "services: {}\n",
+	})
+
+	artifacts, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	want := []Artifact{
+		{Target: TargetDockerfile, Files: []string{"Dockerfile"}},
+		{Target: TargetDockerfile, Files: []string{"api/Containerfile"}},
+		{Target: TargetDockerfile, Files: []string{"api/worker.Dockerfile"}},
+		{Target: TargetCompose, Files: []string{"compose.yaml", "compose.override.yaml"}},
+		{Target: TargetCompose, Files: []string{"compose.yaml", "docker-compose.prod.yml"}},
+		{Target: TargetCompose, Files: []string{"deploy/docker-compose.yml", "deploy/docker-compose.override.yml"}},
+		{Target: TargetCompose, Files: []string{"legacy/docker-compose.staging.yaml"}},
+	}
+	if !reflect.DeepEqual(artifacts, want) {
+		t.Fatalf("artifacts =\n%v\nwant\n%v", artifacts, want)
+	}
+}
+
+func TestDiscoverHonorsGitignoreAndExclude(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":                     "build/\n*.generated.Dockerfile\n/tmp\n",
+		"Dockerfile":                     "FROM alpine\n",
+		"app.generated.Dockerfile":       "FROM alpine\n",
+		"build/Dockerfile":               "FROM alpine\n",
+		"tmp/Dockerfile":                 "FROM alpine\n",
+		"services/tmp/Dockerfile":        "FROM alpine\n",
+		"services/.gitignore":            "*.Dockerfile\n!keep.Dockerfile\n",
+		"services/drop.Dockerfile":       "FROM alpine\n",
+		"services/keep.Dockerfile":       "FROM alpine\n",
+		"vendor/lib/Dockerfile":          "FROM alpine\n",
+		"examples/testdata/compose.yaml": "services: {}\n",
+		".git/Dockerfile":                "FROM alpine\n",
+	})
+
+	artifacts, err := Discover(root, WithExclude("vendor/", "**/testdata/**"))
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	var got []string
+	for _, artifact := range artifacts {
+		got = append(got, artifact.Files...)
+	}
+	want := []string{"Dockerfile", "services/keep.Dockerfile", "services/tmp/Dockerfile"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	artifacts, err = Discover(root, WithGitignore(false))
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(artifacts) != 9 {
+		t.Fatalf("artifacts without gitignore = %d, want 9: %v", len(artifacts), artifacts)
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{pattern: "*.log", path: "a/b/debug.log", want: true},
+		{pattern: "/root.txt", path: "a/root.txt", want: false},
+		{pattern: "docs/*.md", path: "docs/a.md", want: true},
+		{pattern: "docs/*.md", path: "docs/x/a.md", want: false},
+		{pattern: "docs/**/*.md", path: "docs/x/y/a.md", want: true},
+		{pattern: "**/cache", path: "a/cache", isDir: true, want: true},
+		{pattern: "out/", path: "out", isDir: false, want: false},
+		{pattern: "out/", path: "x/out", isDir: true, want: true},
+		{pattern: "file[0-9].txt", path: "file7.txt", want: true},
+	}
+	for _, tt := range tests {
+		p, ok := compilePattern(tt.pattern)
+		if !ok {
+			t.Fatalf("compilePattern(%q) failed", tt.pattern)
+		}
+		if got := p.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q.match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// pattern is one .gitignore line compiled to a regular expression over
+// slash-separated paths relative to the directory of the .gitignore file.
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func (p pattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
+
+// compilePattern follows the .gitignore rules: a pattern containing a slash
+// is anchored to its base directory, otherwise it matches at any depth; a
+// trailing slash restricts it to directories and ** spans directories.
+func compilePattern(line string) (pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	var p pattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return pattern{}, false
+	}
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more leading directories.
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// ignoreFile holds the patterns of one .gitignore and the directory, relative
+// to the scan root, it applies to.
+type ignoreFile struct {
+	base     string
+	patterns []pattern
+}
+
+func loadIgnoreFile(path string, base string) (*ignoreFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	ignore := &ignoreFile{base: base}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := compilePattern(scanner.Text()); ok {
+			ignore.patterns = append(ignore.patterns, p)
+		}
+	}
+	return ignore, scanner.Err()
+}
+
+// matcher evaluates .gitignore files from the root down; as in git, the last
+// matching pattern decides and deeper files take precedence.
+type matcher struct {
+	files []*ignoreFile
+}
+
+func (m *matcher) add(file *ignoreFile) {
+	m.files = append(m.files, file)
+}
+
+func (m *matcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, file := range m.files {
+		sub, ok := relativeTo(file.base, rel)
+		if !ok {
+			continue
+		}
+		for _, p := range file.patterns {
+			if p.match(sub, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+func relativeTo(base string, rel string) (string, bool) {
+	if base == "" || base == "." {
+		return rel, true
+	}
+	if !strings.HasPrefix(rel, base+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(rel, base+"/"), true
+}
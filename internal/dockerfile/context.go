@@ -1,6 +1,11 @@
 package dockerfile
 
-import "github.com/moby/buildkit/frontend/dockerfile/instructions"
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+)
 
 func instructionStage(index int, stg *instructions.Stage) DockerStageState {
 	return DockerStageState{
@@ -13,10 +18,14 @@ func instructionStage(index int, stg *instructions.Stage) DockerStageState {
 }
 
 type DockerStageEval struct {
-	Stages   []DockerStageState
-	Final    DockerStageState
-	hasFinal bool
-	command  instructions.Command
+	// GlobalArgs are the ARGs declared before the first FROM.
+	GlobalArgs map[string]Tracked[AbsString]
+	Stages     []DockerStageState
+	Final      DockerStageState
+	hasFinal   bool
+	// lines are the Dockerfile source lines, used to narrow locations.
+	lines   []string
+	command instructions.Command
 }
 
 func (d *DockerStageEval) startStage(stg DockerStageState) {
@@ -27,6 +36,19 @@ func (d *DockerStageEval) startStage(stg DockerStageState) {
 	d.hasFinal = true
 }
 
+// stage finds a completed stage by name, as referenced from FROM.
+func (d *DockerStageEval) stage(name string) (DockerStageState, bool) {
+	for _, stg := range d.Stages {
+		if stg.StageName != "" && strings.EqualFold(stg.StageName, name) {
+			return stg, true
+		}
+	}
+	if d.hasFinal && d.Final.StageName != "" && strings.EqualFold(d.Final.StageName, name) {
+		return d.Final, true
+	}
+	return DockerStageState{}, false
+}
+
 func (d *DockerStageEval) ensureFinalized() {
 	if !d.hasFinal {
 		return
@@ -57,7 +79,12 @@ type Tracked[T any] struct {
 	Location SourceRef
 }
 
+// SourceRef is a range in the Dockerfile. Lines are 1-based and characters
+// are 0-based; End is exclusive.
 type SourceRef struct {
+	// File is the Dockerfile path, relative to the base directory when one
+	// is configured.
+	File  string
 	Start Position
 	End   Position
 }
@@ -67,6 +94,80 @@ type Position struct {
 	Character int
 }
 
+// narrow returns the range of text inside the instruction spanned by ref,
+// given the source lines of the Dockerfile. offset is the byte offset of text
+// in raw, the instruction as the rule matched it; it selects which occurrence
+// to highlight when the text repeats. Occurrences are counted after the
+// instruction keyword unless the match starts inside it. Text that cannot be
+// found in the source, e.g. a value produced by ARG expansion, keeps the whole
+// instruction.
+func narrow(ref SourceRef, lines []string, raw string, text string, offset int) SourceRef {
+	if text == "" || ref.Start.Line < 1 || ref.End.Line > len(lines) || ref.Start.Line > ref.End.Line {
+		return ref
+	}
+	source := lines[ref.Start.Line-1 : ref.End.Line]
+
+	skip := true
+	occurrence := 0
+	if offset >= 0 && offset <= len(raw) {
+		keyword := keywordEnd(raw)
+		if offset < keyword {
+			skip = false
+			keyword = 0
+		}
+		occurrence = strings.Count(raw[keyword:offset], text)
+	}
+
+	for _, fold := range []bool{false, true} {
+		needle := text
+		if fold {
+			needle = strings.ToLower(text)
+		}
+		remaining := occurrence
+		for i, line := range source {
+			from := 0
+			if i == 0 && skip {
+				from = keywordEnd(line)
+			}
+			if fold {
+				lower := strings.ToLower(line)
+				if len(lower) != len(line) {
+					continue
+				}
+				line = lower
+			}
+			for {
+				index := strings.Index(line[from:], needle)
+				if index < 0 {
+					break
+				}
+				index += from
+				if remaining > 0 {
+					remaining--
+					from = index + len(needle)
+					continue
+				}
+				start := utf8.RuneCountInString(line[:index])
+				narrowed := ref
+				narrowed.Start = Position{Line: ref.Start.Line + i, Character: start}
+				narrowed.End = Position{Line: ref.Start.Line + i, Character: start + utf8.RuneCountInString(text)}
+				return narrowed
+			}
+		}
+	}
+	return ref
+}
+
+// keywordEnd returns the byte offset just past the instruction keyword.
+func keywordEnd(line string) int {
+	start := len(line) - len(strings.TrimLeft(line, " \t"))
+	end := strings.IndexAny(line[start:], " \t")
+	if end < 0 {
+		return len(line)
+	}
+	return start + end
+}
+
 type AbsString struct {
 	Kind  string
 	Known string
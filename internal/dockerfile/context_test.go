@@ -0,0 +1,74 @@
+package dockerfile
+
+import "testing"
+
+func TestNarrowHighlightsRepeatedTokenByOffset(t *testing.T) {
+	lines := []string{
+		"FROM from:latest",
+		"USER 10:0",
+		"RUN curl -fsSL https://example.com/a.sh && \\",
+		"    curl -fsSL https://example.com/a.sh | sh",
+	}
+
+	tests := []struct {
+		name   string
+		line   int
+		end    int
+		raw    string
+		text   string
+		offset int
+		want   SourceRef
+	}{
+		{
+			name:   "token repeated inside an argument",
+			line:   2,
+			raw:    "USER 10:0",
+			text:   "0",
+			offset: 8,
+			want:   SourceRef{Start: Position{Line: 2, Character: 8}, End: Position{Line: 2, Character: 9}},
+		},
+		{
+			name:   "token repeated on a continuation line",
+			line:   3,
+			end:    4,
+			raw:    "RUN curl -fsSL https://example.com/a.sh &&     curl -fsSL https://example.com/a.sh | sh",
+			text:   "curl -fsSL https://example.com/a.sh",
+			offset: 47,
+			want:   SourceRef{Start: Position{Line: 4, Character: 4}, End: Position{Line: 4, Character: 39}},
+		},
+		{
+			name:   "unknown offset skips the keyword",
+			line:   1,
+			raw:    "FROM from:latest",
+			text:   "FROM",
+			offset: -1,
+			want:   SourceRef{Start: Position{Line: 1, Character: 5}, End: Position{Line: 1, Character: 9}},
+		},
+		{
+			name:   "match starting at the keyword",
+			line:   1,
+			raw:    "FROM from:latest",
+			text:   "FROM from",
+			offset: 0,
+			want:   SourceRef{Start: Position{Line: 1, Character: 0}, End: Position{Line: 1, Character: 9}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := tt.end
+			if end == 0 {
+				end = tt.line
+			}
+			ref := SourceRef{Start: Position{Line: tt.line}, End: Position{Line: end, Character: len(lines[end-1])}}
+			if got := narrow(ref, lines, tt.raw, tt.text, tt.offset); got != tt.want {
+				t.Fatalf("narrow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	ref := SourceRef{Start: Position{Line: 2}, End: Position{Line: 2, Character: 9}}
+	if got := narrow(ref, lines, "USER 1000:0", "1000:0", 5); got != ref {
+		t.Fatalf("expanded text must keep the instruction, got %+v", got)
+	}
+}
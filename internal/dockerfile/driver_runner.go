@@ -3,7 +3,10 @@ package dockerfile
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/katvixlab/contain-sentry/internal/engine"
 	"github.com/katvixlab/contain-sentry/internal/entities"
@@ -19,16 +22,23 @@ type DockerfileDriver struct {
 	dom       *DockerStageEval
 	finalized bool
 	eofSent   bool
+	stage     string
+	stages    int
+	// escapeToken is the Dockerfile escape character used for expansion.
+	escapeToken rune
 }
 
 func NewDockerfileDriver(df *Dockerfile, dom *DockerStageEval) *DockerfileDriver {
 	if dom == nil {
 		dom = &DockerStageEval{}
 	}
+	driver := &DockerfileDriver{df: df, dom: dom}
 	if df != nil {
 		df.context = dom
+		dom.lines = df.lines
+		driver.escapeToken = df.escapeToken
 	}
-	return &DockerfileDriver{df: df, dom: dom}
+	return driver
 }
 
 func (d *DockerfileDriver) Target() string {
@@ -52,7 +62,7 @@ func (d *DockerfileDriver) Next(ctx context.Context) (engine.Step, bool, error)
 		}
 		if !d.eofSent {
 			d.eofSent = true
-			return engine.Step{Target: targetDockerfile, Subject: "eof", Raw: ""}, true, nil
+			return engine.Step{Target: targetDockerfile, Subject: "eof", File: d.stepFile(), Raw: "", Stage: d.stage, Location: d.eofLocation()}, true, nil
 		}
 		return engine.Step{}, false, nil
 	}
@@ -64,14 +74,68 @@ func (d *DockerfileDriver) Next(ctx context.Context) (engine.Step, bool, error)
 	if err != nil {
 		return engine.Step{}, false, err
 	}
+	if stage, ok := instruction.(*instructions.Stage); ok {
+		d.stages++
+		d.stage = stageName(stage, d.stages-1)
+	}
 
-	return engine.Step{
+	step := engine.Step{
 		Target:   targetDockerfile,
+		File:     d.stepFile(),
+		Stage:    d.stage,
 		Subject:  dockerSubject(instruction),
 		Raw:      dockerRaw(instruction),
-		Location: nodeLocation(node),
+		Location: nodeLocation(d.df.file, node, d.df.lines),
 		Command:  instruction,
-	}, true, nil
+		Comments: append([]string{}, node.PrevComment...),
+	}
+	if expandInstructions[step.Subject] {
+		if value := expandWord(d.escapeToken, step.Raw, d.scope(step.Subject == "from")); value.Kind != AbsKindLiteral {
+			step.Resolved = value.Known
+		}
+	}
+	return step, true, nil
+}
+
+// scope returns the variables visible to the next instruction. FROM only
+// sees global ARGs; inside a stage ENV shadows ARG as it does in the builder.
+func (d *DockerfileDriver) scope(global bool) variableScope {
+	if global || !d.dom.hasFinal {
+		return variableScope(d.dom.GlobalArgs).with(nil)
+	}
+	return variableScope(d.dom.Final.Args).with(d.dom.Final.Env)
+}
+
+// argValue resolves an ARG declaration: a build arg wins over the default,
+// and a stage ARG without a default inherits the global value.
+func (d *DockerfileDriver) argValue(kv instructions.KeyValuePairOptional, scope variableScope, inStage bool) AbsString {
+	if value, ok := d.df.buildArgs[kv.Key]; ok {
+		return AbsString{Kind: AbsKindResolved, Known: value, Expr: kv.ValueString()}
+	}
+	if kv.Value != nil {
+		return expandWord(d.escapeToken, *kv.Value, scope)
+	}
+	if global, ok := d.dom.GlobalArgs[kv.Key]; ok && inStage {
+		return global.Val
+	}
+	return AbsString{Kind: AbsKindUnresolved, Expr: "${" + kv.Key + "}"}
+}
+
+// eofLocation anchors aggregate findings to the file; they have no line.
+func (d *DockerfileDriver) eofLocation() any {
+	if d.df.file == "" {
+		return nil
+	}
+	return SourceRef{File: d.df.file}
+}
+
+// stepFile is the scan-root relative path; it stays empty for single-file runs
+// so their fingerprints do not depend on how the path was spelled.
+func (d *DockerfileDriver) stepFile() string {
+	if d.df.baseDir == "" {
+		return ""
+	}
+	return d.df.file
 }
 
 func (d *DockerfileDriver) Transfer(ctx context.Context, step engine.Step) error {
@@ -85,13 +149,19 @@ func (d *DockerfileDriver) Transfer(ctx context.Context, step engine.Step) error
 	switch command := step.Command.(type) {
 	case *instructions.Stage:
 		stg := instructionStage(len(d.dom.Stages), command)
+		stg.BaseImage = expandWord(d.escapeToken, command.BaseName, d.scope(true)).Known
+		if parent, ok := d.dom.stage(stg.BaseImage); ok {
+			for key, value := range parent.Env {
+				stg.Env[key] = value
+			}
+		}
 		d.dom.startStage(stg)
 	case *instructions.UserCommand:
 		if !d.dom.hasFinal {
 			return nil
 		}
 		d.dom.Final.User = Tracked[AbsString]{
-			Val:      AbsString{Kind: "literal", Known: command.User},
+			Val:      expandWord(d.escapeToken, command.User, d.scope(false)),
 			Location: location,
 		}
 		d.dom.Final.HasUser = true
@@ -100,7 +170,7 @@ func (d *DockerfileDriver) Transfer(ctx context.Context, step engine.Step) error
 			return nil
 		}
 		d.dom.Final.Workdir = Tracked[AbsString]{
-			Val:      AbsString{Kind: "literal", Known: command.Path},
+			Val:      expandWord(d.escapeToken, command.Path, d.scope(false)),
 			Location: location,
 		}
 	case *instructions.EnvCommand:
@@ -110,26 +180,24 @@ func (d *DockerfileDriver) Transfer(ctx context.Context, step engine.Step) error
 		if d.dom.Final.Env == nil {
 			d.dom.Final.Env = map[string]Tracked[AbsString]{}
 		}
+		scope := d.scope(false)
 		for _, kv := range command.Env {
 			d.dom.Final.Env[kv.Key] = Tracked[AbsString]{
-				Val:      AbsString{Kind: "literal", Known: kv.Value},
+				Val:      expandWord(d.escapeToken, kv.Value, scope),
 				Location: location,
 			}
 		}
 	case *instructions.ArgCommand:
-		if !d.dom.hasFinal {
-			return nil
+		args := &d.dom.GlobalArgs
+		if d.dom.hasFinal {
+			args = &d.dom.Final.Args
 		}
-		if d.dom.Final.Args == nil {
-			d.dom.Final.Args = map[string]Tracked[AbsString]{}
+		if *args == nil {
+			*args = map[string]Tracked[AbsString]{}
 		}
 		for _, kv := range command.Args {
-			value := ""
-			if kv.Value != nil {
-				value = *kv.Value
-			}
-			d.dom.Final.Args[kv.Key] = Tracked[AbsString]{
-				Val:      AbsString{Kind: "literal", Known: value},
+			(*args)[kv.Key] = Tracked[AbsString]{
+				Val:      d.argValue(kv, d.scope(false), d.dom.hasFinal),
 				Location: location,
 			}
 		}
@@ -184,7 +252,7 @@ func (r *DockerfileRunner) Eval(ctx context.Context, dom any, rule entities.Base
 	if !strings.EqualFold(strings.TrimSpace(rule.Subject), strings.TrimSpace(step.Subject)) {
 		return nil
 	}
-	if rule.Expression == nil {
+	if rule.Expression == nil || !slices.Contains(dockerfileExpressionKinds, rule.Expression.Kind()) {
 		return nil
 	}
 
@@ -192,11 +260,24 @@ func (r *DockerfileRunner) Eval(ctx context.Context, dom any, rule entities.Base
 		return r.evalDockerfileConstraint(rule, step, dom, expression)
 	}
 
-	if !rule.Expression.MatchCommand(step.Subject, step.Command, step.Raw) {
+	raw := step.Raw
+	if step.Resolved != "" {
+		raw = step.Resolved
+	}
+	if !rule.Expression.MatchCommand(step.Subject, step.Command, raw) {
 		return nil
 	}
 
-	return []entities.Finding{engine.BuildFinding(rule, step)}
+	finding := engine.BuildFinding(rule, step)
+	if highlighter, ok := rule.Expression.(entities.Highlighter); ok {
+		ref, isRef := step.Location.(SourceRef)
+		state, isState := dom.(*DockerStageEval)
+		if isRef && isState {
+			text, offset := highlighter.Highlight(step.Subject, step.Command, raw)
+			finding.Location = narrow(ref, state.lines, raw, text, offset)
+		}
+	}
+	return []entities.Finding{finding}
 }
 
 func (r *DockerfileRunner) evalDockerfileConstraint(rule entities.BaseRule, step engine.Step, dom any, expression *entities.ExpressionDockerfileConstraint) []entities.Finding {
@@ -222,20 +303,32 @@ func matchesDockerfileConstraint(dom *DockerStageEval, check string) bool {
 	if dom == nil || len(dom.Stages) == 0 {
 		return false
 	}
-
-	finalStage := dom.Stages[len(dom.Stages)-1]
-	switch check {
-	case "missing_user_final_stage":
-		return !finalStage.HasUser
-	case "missing_healthcheck_final_stage":
-		return !finalStage.HasHealthcheck
-	case "missing_copy_from_in_multistage":
-		return len(dom.Stages) >= 2 && !finalStage.HasCopyFrom
-	case "single_stage_with_build_tools":
-		return len(dom.Stages) == 1 && finalStage.HasBuildTooling
-	default:
-		return false
+	for _, constraint := range dockerfileConstraints {
+		if constraint.check == check {
+			return constraint.matches(dom.Stages, dom.Stages[len(dom.Stages)-1])
+		}
 	}
+	return false
+}
+
+// dockerfileConstraints are the checks of dockerfile_constraint expressions,
+// evaluated over the completed stages and the final stage.
+var dockerfileConstraints = []struct {
+	check   string
+	matches func(stages []DockerStageState, final DockerStageState) bool
+}{
+	{"missing_user_final_stage", func(_ []DockerStageState, final DockerStageState) bool {
+		return !final.HasUser
+	}},
+	{"missing_healthcheck_final_stage", func(_ []DockerStageState, final DockerStageState) bool {
+		return !final.HasHealthcheck
+	}},
+	{"missing_copy_from_in_multistage", func(stages []DockerStageState, final DockerStageState) bool {
+		return len(stages) >= 2 && !final.HasCopyFrom
+	}},
+	{"single_stage_with_build_tools", func(stages []DockerStageState, final DockerStageState) bool {
+		return len(stages) == 1 && final.HasBuildTooling
+	}},
 }
 
 func runLooksLikeBuildTooling(raw string) bool {
@@ -262,44 +355,49 @@ func runLooksLikeBuildTooling(raw string) bool {
 	return false
 }
 
+// Driver returns a fresh driver over the parsed Dockerfile.
+func (df *Dockerfile) Driver() engine.Driver {
+	return NewDockerfileDriver(df, &DockerStageEval{})
+}
+
 func (df *Dockerfile) Validate(ctx context.Context, rules []entities.BaseRule) ([]entities.Finding, error) {
-	dom := &DockerStageEval{}
-	driver := NewDockerfileDriver(df, dom)
 	eng := engine.New(rules, &DockerfileRunner{})
-	return eng.Run(ctx, driver)
+	return eng.Run(ctx, df.Driver())
 }
 
 func dockerSubject(command any) string {
-	switch command.(type) {
-	case *instructions.Stage:
-		return "from"
-	case *instructions.RunCommand:
-		return "run"
-	case *instructions.UserCommand:
-		return "user"
-	case *instructions.EnvCommand:
-		return "env"
-	case *instructions.WorkdirCommand:
-		return "workdir"
-	case *instructions.ArgCommand:
-		return "arg"
-	case *instructions.ShellCommand:
-		return "shell"
-	case *instructions.EntrypointCommand:
-		return "entrypoint"
-	case *instructions.CmdCommand:
-		return "cmd"
-	case *instructions.CopyCommand:
-		return "copy"
-	case *instructions.AddCommand:
-		return "add"
-	case *instructions.HealthCheckCommand:
-		return "healthcheck"
-	case *instructions.ExposeCommand:
-		return "expose"
-	default:
-		return "unknown"
+	for _, subject := range dockerSubjects {
+		if subject.matches(command) {
+			return subject.name
+		}
 	}
+	return "unknown"
+}
+
+// dockerSubjects map parsed instructions onto rule subjects. The driver also
+// emits an "eof" step after the last instruction.
+var dockerSubjects = []struct {
+	name    string
+	matches func(command any) bool
+}{
+	{"from", isInstruction[*instructions.Stage]},
+	{"run", isInstruction[*instructions.RunCommand]},
+	{"user", isInstruction[*instructions.UserCommand]},
+	{"env", isInstruction[*instructions.EnvCommand]},
+	{"workdir", isInstruction[*instructions.WorkdirCommand]},
+	{"arg", isInstruction[*instructions.ArgCommand]},
+	{"shell", isInstruction[*instructions.ShellCommand]},
+	{"entrypoint", isInstruction[*instructions.EntrypointCommand]},
+	{"cmd", isInstruction[*instructions.CmdCommand]},
+	{"copy", isInstruction[*instructions.CopyCommand]},
+	{"add", isInstruction[*instructions.AddCommand]},
+	{"healthcheck", isInstruction[*instructions.HealthCheckCommand]},
+	{"expose", isInstruction[*instructions.ExposeCommand]},
+}
+
+func isInstruction[T any](command any) bool {
+	_, ok := command.(T)
+	return ok
 }
 
 func dockerRaw(command any) string {
@@ -321,12 +419,61 @@ func dockerRaw(command any) string {
 	}
 }
 
-func nodeLocation(node *parser.Node) SourceRef {
+func stageName(stage *instructions.Stage, index int) string {
+	if stage.Name != "" {
+		return stage.Name
+	}
+	return strconv.Itoa(index)
+}
+
+// nodeLocation spans the instruction from its keyword to the end of its last
+// line.
+func nodeLocation(file string, node *parser.Node, lines []string) SourceRef {
 	if node == nil {
-		return SourceRef{}
+		return SourceRef{File: file}
 	}
-	return SourceRef{
-		Start: Position{Line: node.StartLine, Character: 0},
-		End:   Position{Line: node.EndLine, Character: 0},
+	ref := SourceRef{
+		File:  file,
+		Start: Position{Line: node.StartLine},
+		End:   Position{Line: node.EndLine},
 	}
+	if node.StartLine < 1 || node.EndLine > len(lines) || node.StartLine > node.EndLine {
+		return ref
+	}
+	first := lines[node.StartLine-1]
+	ref.Start.Character = utf8.RuneCountInString(first[:len(first)-len(strings.TrimLeft(first, " \t"))])
+	ref.End.Character = utf8.RuneCountInString(lines[node.EndLine-1])
+	return ref
+}
+
+// dockerfileExpressionKinds are the expression kinds the Dockerfile runner
+// evaluates; rules of other kinds never match.
+var dockerfileExpressionKinds = []string{"regex", "user_id_compare", "dockerfile_constraint", "dsl"}
+
+// Subjects lists the step subjects Dockerfile rules can match.
+func Subjects() []string {
+	subjects := make([]string, 0, len(dockerSubjects)+1)
+	for _, subject := range dockerSubjects {
+		subjects = append(subjects, subject.name)
+	}
+	return append(subjects, "eof")
+}
+
+// ExpressionKinds lists the expression kinds the Dockerfile runner evaluates.
+func ExpressionKinds() []string {
+	return append([]string{}, dockerfileExpressionKinds...)
+}
+
+// ConstraintChecks lists the checks understood by matchesDockerfileConstraint.
+func ConstraintChecks() []string {
+	checks := make([]string, 0, len(dockerfileConstraints))
+	for _, constraint := range dockerfileConstraints {
+		checks = append(checks, constraint.check)
+	}
+	return checks
+}
+
+// KnownSelect reports whether a dsl expression select path is evaluated.
+func KnownSelect(selectPath string) bool {
+	return entities.KnownDSLSelect(selectPath)
 }
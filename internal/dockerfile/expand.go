@@ -0,0 +1,174 @@
+package dockerfile
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
+)
+
+const (
+	AbsKindLiteral    = "literal"
+	AbsKindResolved   = "resolved"
+	AbsKindUnresolved = "unresolved"
+)
+
+// IsResolved reports whether the value is fully known after expansion.
+func (a AbsString) IsResolved() bool {
+	return a.Kind != AbsKindUnresolved
+}
+
+// variableScope exposes ARG and ENV values to the shell lexer. Unresolved
+// values are reported as unset so that their references stay verbatim.
+type variableScope map[string]Tracked[AbsString]
+
+func (s variableScope) Get(key string) (string, bool) {
+	value, ok := s[key]
+	if !ok || !value.Val.IsResolved() {
+		return "", false
+	}
+	return value.Val.Known, true
+}
+
+func (s variableScope) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// with returns a scope where the entries of overlay shadow those of s.
+func (s variableScope) with(overlay map[string]Tracked[AbsString]) variableScope {
+	merged := make(variableScope, len(s)+len(overlay))
+	for key, value := range s {
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		merged[key] = value
+	}
+	return merged
+}
+
+// expandInstructions lists the subjects whose arguments the builder expands;
+// RUN, CMD and ENTRYPOINT are left to the shell at build or run time.
+var expandInstructions = map[string]bool{
+	"from":    true,
+	"user":    true,
+	"workdir": true,
+	"env":     true,
+	"arg":     true,
+	"copy":    true,
+	"add":     true,
+	"expose":  true,
+}
+
+// newLexer returns a lexer for one expansion. skipUnset keeps references to
+// unset variables verbatim instead of expanding them to their defaults.
+func newLexer(escapeToken rune, skipUnset bool) *shell.Lex {
+	if escapeToken == 0 {
+		escapeToken = '\\'
+	}
+	lex := shell.NewLex(escapeToken)
+	lex.SkipUnsetEnv = skipUnset
+	lex.RawQuotes = true
+	lex.RawEscapes = true
+	return lex
+}
+
+// expandWord substitutes known variables in word. References to unknown
+// variables are kept verbatim and mark the value unresolved, unless every
+// reference carries a default such as ${UID:-1000}.
+func expandWord(escapeToken rune, word string, scope variableScope) AbsString {
+	result, err := newLexer(escapeToken, true).ProcessWordWithMatches(word, scope)
+	if err != nil {
+		return AbsString{Kind: AbsKindUnresolved, Known: word, Expr: word}
+	}
+	if len(result.Matched) == 0 && len(result.Unmatched) == 0 {
+		return AbsString{Kind: AbsKindLiteral, Known: word}
+	}
+
+	value := AbsString{Kind: AbsKindResolved, Known: result.Result, Expr: word, Deps: sortedNames(result.Matched, result.Unmatched)}
+	if len(result.Unmatched) == 0 {
+		return value
+	}
+	references := scanReferences(word)
+	for name := range result.Unmatched {
+		if count := references[name]; count.total == 0 || count.total != count.defaulted {
+			value.Kind = AbsKindUnresolved
+			return value
+		}
+	}
+
+	defaulted, err := newLexer(escapeToken, false).ProcessWordWithMatches(word, scope)
+	if err != nil {
+		value.Kind = AbsKindUnresolved
+		return value
+	}
+	value.Known = defaulted.Result
+	return value
+}
+
+// referenceCount counts the references to a variable and how many of them
+// carry a default, as in ${NAME:-value} or ${NAME-value}.
+type referenceCount struct {
+	total     int
+	defaulted int
+}
+
+// scanReferences counts the $NAME and ${NAME...} references in word by name.
+func scanReferences(word string) map[string]referenceCount {
+	references := map[string]referenceCount{}
+	for i := 0; i < len(word); i++ {
+		if word[i] != '$' || i+1 >= len(word) {
+			continue
+		}
+		braced := word[i+1] == '{'
+		start := i + 1
+		if braced {
+			start++
+		}
+		end := start
+		for end < len(word) && isNameByte(word[end], end == start) {
+			end++
+		}
+		if end == start {
+			continue
+		}
+		name := word[start:end]
+		count := references[name]
+		switch {
+		case !braced:
+			count.total++
+		case end < len(word) && strings.IndexByte("}:-+?#%", word[end]) >= 0:
+			count.total++
+			if strings.HasPrefix(word[end:], "-") || strings.HasPrefix(word[end:], ":-") {
+				count.defaulted++
+			}
+		}
+		references[name] = count
+		i = end - 1
+	}
+	return references
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
+
+func sortedNames(sets ...map[string]struct{}) []string {
+	var names []string
+	seen := map[string]struct{}{}
+	for _, set := range sets {
+		for name := range set {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package dockerfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/engine"
+)
+
+func TestExpandWord(t *testing.T) {
+	scope := variableScope{
+		"BASE":    {Val: AbsString{Kind: AbsKindLiteral, Known: "alpine"}},
+		"TAG":     {Val: AbsString{Kind: AbsKindLiteral, Known: "3.20"}},
+		"APP_UID": {Val: AbsString{Kind: AbsKindUnresolved, Expr: "${APP_UID}"}},
+	}
+
+	tests := []struct {
+		word string
+		want AbsString
+	}{
+		{word: "FROM alpine", want: AbsString{Kind: AbsKindLiteral, Known: "FROM alpine"}},
+		{word: "FROM ${BASE}:$TAG AS build", want: AbsString{Kind: AbsKindResolved, Known: "FROM alpine:3.20 AS build", Expr: "FROM ${BASE}:$TAG AS build", Deps: []string{"BASE", "TAG"}}},
+		{word: "USER ${APP_UID}", want: AbsString{Kind: AbsKindUnresolved, Known: "USER ${APP_UID}", Expr: "USER ${APP_UID}", Deps: []string{"APP_UID"}}},
+		{word: "USER ${GID:-1000}", want: AbsString{Kind: AbsKindResolved, Known: "USER 1000", Expr: "USER ${GID:-1000}", Deps: []string{"GID"}}},
+		{word: "USER ${GID-1000}:${GID_SUFFIX:-0}", want: AbsString{Kind: AbsKindResolved, Known: "USER 1000:0", Expr: "USER ${GID-1000}:${GID_SUFFIX:-0}", Deps: []string{"GID", "GID_SUFFIX"}}},
+		{word: "USER ${GID}", want: AbsString{Kind: AbsKindUnresolved, Known: "USER ${GID}", Expr: "USER ${GID}", Deps: []string{"GID"}}},
+		{word: "USER ${GID:-1000}:$GID", want: AbsString{Kind: AbsKindUnresolved, Known: "USER ${GID:-1000}:$GID", Expr: "USER ${GID:-1000}:$GID", Deps: []string{"GID"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got := expandWord('\\', tt.word, scope)
+			if got.Kind != tt.want.Kind || got.Known != tt.want.Known || got.Expr != tt.want.Expr {
+				t.Fatalf("expandWord() = %+v, want %+v", got, tt.want)
+			}
+			if len(got.Deps) != len(tt.want.Deps) {
+				t.Fatalf("Deps = %v, want %v", got.Deps, tt.want.Deps)
+			}
+			for i := range got.Deps {
+				if got.Deps[i] != tt.want.Deps[i] {
+					t.Fatalf("Deps = %v, want %v", got.Deps, tt.want.Deps)
+				}
+			}
+		})
+	}
+}
+
+func TestDockerfileArgAndEnvScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	content := `ARG REGISTRY=docker.io
+ARG VERSION=1.0
+FROM ${REGISTRY}/golang:1.22 AS build
+ARG VERSION
+ENV APP_HOME=/srv/app-${VERSION}
+WORKDIR ${APP_HOME}
+FROM build AS runtime
+ARG APP_UID
+USER ${APP_UID}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dom := runDockerfile(t, path, nil)
+	if len(dom.Stages) != 2 {
+		t.Fatalf("stages = %d, want 2", len(dom.Stages))
+	}
+	build, runtime := dom.Stages[0], dom.Stages[1]
+	if build.BaseImage != "docker.io/golang:1.22" {
+		t.Fatalf("BaseImage = %q", build.BaseImage)
+	}
+	if got := build.Args["VERSION"].Val; got.Known != "1.0" || !got.IsResolved() {
+		t.Fatalf("VERSION = %+v, want inherited global value", got)
+	}
+	if got := build.Workdir.Val; got.Kind != AbsKindResolved || got.Known != "/srv/app-1.0" {
+		t.Fatalf("Workdir = %+v", got)
+	}
+	if got := runtime.Env["APP_HOME"].Val.Known; got != "/srv/app-1.0" {
+		t.Fatalf("runtime APP_HOME = %q, want value inherited from build stage", got)
+	}
+	if got := runtime.User.Val; got.IsResolved() || got.Known != "${APP_UID}" {
+		t.Fatalf("User = %+v, want unresolved", got)
+	}
+
+	dom = runDockerfile(t, path, map[string]string{"APP_UID": "10001", "VERSION": "2.0", "UNDECLARED": "x"})
+	build, runtime = dom.Stages[0], dom.Stages[1]
+	if got := runtime.User.Val; got.Kind != AbsKindResolved || got.Known != "10001" {
+		t.Fatalf("User = %+v, want build arg value", got)
+	}
+	if got := build.Workdir.Val.Known; got != "/srv/app-2.0" {
+		t.Fatalf("Workdir = %q, want build arg value", got)
+	}
+	if _, ok := build.Args["UNDECLARED"]; ok {
+		t.Fatal("undeclared build arg leaked into stage scope")
+	}
+}
+
+func runDockerfile(t *testing.T, path string, buildArgs map[string]string) *DockerStageEval {
+	t.Helper()
+	df, err := NewDockerfile(context.Background(), path, WithBuildArgs(buildArgs))
+	if err != nil {
+		t.Fatalf("NewDockerfile() error = %v", err)
+	}
+	driver := df.Driver()
+	if _, err := engine.New(nil, &DockerfileRunner{}).Run(context.Background(), driver); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return driver.DomainContext().(*DockerStageEval)
+}
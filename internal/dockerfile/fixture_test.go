@@ -27,6 +27,9 @@ func TestDockerfileFixtures(t *testing.T) {
 		{name: "curl pipe shell", file: "curl-pipe-shell.Dockerfile", want: []string{"DF012", "DF013"}},
 		{name: "single stage build tooling", file: "single-stage-build-tools.Dockerfile", want: []string{"DF004", "DF019"}},
 		{name: "secure dockerfile", file: "secure.Dockerfile", want: nil},
+		{name: "inline suppressions", file: "suppressed.Dockerfile", want: nil},
+		{name: "arg expansion", file: "arg-expansion.Dockerfile", want: []string{"DF001", "DF002", "DF006", "DF007"}},
+		{name: "unresolved args", file: "arg-unresolved.Dockerfile", want: []string{"DF002"}},
 	}
 
 	for _, tt := range tests {
@@ -56,6 +59,39 @@ func TestDockerfileFixtures(t *testing.T) {
 	}
 }
 
+func TestDockerfileSuppressedFindingsKeepReason(t *testing.T) {
+	df, err := NewDockerfile(context.Background(), filepath.Join("testdata", "suppressed.Dockerfile"))
+	if err != nil {
+		t.Fatalf("NewDockerfile() error = %v", err)
+	}
+	findings, err := df.Validate(context.Background(), loadDockerfileRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	reasons := map[string]string{}
+	for _, finding := range findings {
+		if finding.Suppression == nil {
+			t.Fatalf("unexpected active finding %s", finding.ID)
+		}
+		reasons[finding.ID] = finding.Suppression.Reason
+	}
+	want := map[string]string{
+		"DF002": "vendor image",
+		"DF006": "entrypoint drops privileges",
+		"DF007": "entrypoint drops privileges",
+		"DF022": "healthcheck is defined by the orchestrator",
+	}
+	if len(reasons) != len(want) {
+		t.Fatalf("suppressed = %v, want %v", reasons, want)
+	}
+	for id, reason := range want {
+		if reasons[id] != reason {
+			t.Fatalf("suppression reason for %s = %q, want %q", id, reasons[id], reason)
+		}
+	}
+}
+
 func loadDockerfileRules(t *testing.T) []entities.BaseRule {
 	t.Helper()
 	var rules []entities.BaseRule
@@ -77,7 +113,75 @@ func readDockerfileJSON(t *testing.T, path string, target any) {
 func findingIDs(findings []entities.Finding) []string {
 	ids := make([]string, 0, len(findings))
 	for _, finding := range findings {
+		if finding.Suppression != nil {
+			continue
+		}
 		ids = append(ids, finding.ID)
 	}
 	return ids
 }
+
+func TestDockerfileLocationsUseBaseDirRelativePath(t *testing.T) {
+	df, err := NewDockerfile(context.Background(), filepath.Join("testdata", "missing-user.Dockerfile"), WithBaseDir("testdata"))
+	if err != nil {
+		t.Fatalf("NewDockerfile() error = %v", err)
+	}
+	findings, err := df.Validate(context.Background(), loadDockerfileRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(findings) == 0 {
+		t.Fatalf("findings are empty")
+	}
+	for _, finding := range findings {
+		location, ok := finding.Location.(SourceRef)
+		if !ok || location.File != "missing-user.Dockerfile" {
+			t.Fatalf("%s location = %#v, want file relative to testdata", finding.ID, finding.Location)
+		}
+	}
+}
+
+func TestDockerfileLocationsHighlightMatchedText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	content := "FROM alpine:latest\n" +
+		"RUN --mount=type=cache,target=/root/.cache \\\n" +
+		"    curl -fsSL https://example.com/install.sh | sh\n" +
+		"  USER root\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	df, err := NewDockerfile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewDockerfile() error = %v", err)
+	}
+	findings, err := df.Validate(context.Background(), loadDockerfileRules(t))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := map[string]SourceRef{
+		"DF001": {Start: Position{Line: 1, Character: 11}, End: Position{Line: 1, Character: 18}},
+		"DF012": {Start: Position{Line: 3, Character: 4}, End: Position{Line: 3, Character: 45}},
+		"DF020": {Start: Position{Line: 2, Character: 4}, End: Position{Line: 2, Character: 42}},
+		"DF006": {Start: Position{Line: 4, Character: 7}, End: Position{Line: 4, Character: 11}},
+		"DF007": {Start: Position{Line: 4, Character: 7}, End: Position{Line: 4, Character: 11}},
+		"DF022": {},
+	}
+	for _, finding := range findings {
+		expected, ok := want[finding.ID]
+		if !ok {
+			continue
+		}
+		location, ok := finding.Location.(SourceRef)
+		if !ok {
+			t.Fatalf("%s location = %#v, want SourceRef", finding.ID, finding.Location)
+		}
+		if location.File != path || location.Start != expected.Start || location.End != expected.End {
+			t.Fatalf("%s location = %+v-%+v, want %+v-%+v", finding.ID, location.Start, location.End, expected.Start, expected.End)
+		}
+		delete(want, finding.ID)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing findings %v", want)
+	}
+}
@@ -1,9 +1,12 @@
 package dockerfile
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/katvixlab/contain-sentry/cmd/containsentry/config"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
@@ -14,9 +17,31 @@ type Dockerfile struct {
 	context *DockerStageEval
 	nodes   []*parser.Node
 	ctx     context.Context
+	file    string
+	baseDir string
+	lines   []string
+
+	escapeToken rune
+	buildArgs   map[string]string
+}
+
+type Option func(*Dockerfile)
+
+// WithBaseDir reports the Dockerfile path relative to dir in locations.
+func WithBaseDir(dir string) Option {
+	return func(x *Dockerfile) {
+		x.baseDir = dir
+	}
+}
+
+// WithBuildArgs supplies values for declared ARGs, like `docker build --build-arg`.
+func WithBuildArgs(args map[string]string) Option {
+	return func(x *Dockerfile) {
+		x.buildArgs = args
+	}
 }
 
-func NewDockerfile(ctx context.Context, path string) (*Dockerfile, error) {
+func NewDockerfile(ctx context.Context, path string, opts ...Option) (*Dockerfile, error) {
 	logger := config.FromCtx(ctx)
 
 	file, err := os.Open(path)
@@ -31,16 +56,61 @@ func NewDockerfile(ctx context.Context, path string) (*Dockerfile, error) {
 		}
 	}(file)
 
-	var r io.Reader = file
-	parse, err := parser.Parse(r)
+	content, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Error reading Dockerfile", zap.Error(err))
+		return nil, err
+	}
+	parse, err := parser.Parse(bytes.NewReader(content))
 	if err != nil {
 		logger.Error("Error parsing Dockerfile", zap.Error(err))
 		return nil, err
 	}
 
-	return &Dockerfile{
+	df := &Dockerfile{
 		context: &DockerStageEval{},
 		nodes:   parse.AST.Children,
 		ctx:     ctx,
-	}, nil
+		file:    path,
+		lines:   sourceLines(content),
+
+		escapeToken: parse.EscapeToken,
+	}
+	for _, opt := range opts {
+		opt(df)
+	}
+	df.file = relativePath(df.baseDir, path)
+	return df, nil
+}
+
+// File returns the Dockerfile path as reported in finding locations.
+func (df *Dockerfile) File() string {
+	return df.file
+}
+
+func sourceLines(content []byte) []string {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+func relativePath(baseDir string, path string) string {
+	if baseDir == "" {
+		return path
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
 }
@@ -10,13 +10,26 @@ import (
 type Step struct {
 	Target   string
 	Subject  string
+	File     string
 	Path     string
 	Service  string
+	Stage    string
 	Raw      string
+	Resolved string
 	Value    any
 	Present  bool
 	Location any
 	Command  any
+	Comments []string
+	// Occurrence counts the earlier steps of the driver with the same
+	// subject, scope and anchor. The engine sets it so that repeated
+	// instructions, e.g. two `USER root` lines in one stage, get distinct
+	// fingerprints.
+	Occurrence int
+
+	// Suppressions are waivers resolved by the driver itself, e.g. from
+	// structured configuration rather than comments.
+	Suppressions []entities.Suppression
 }
 
 type Driver interface {
@@ -31,6 +44,14 @@ type Runner interface {
 	Eval(ctx context.Context, dom any, rule entities.BaseRule, step Step) []entities.Finding
 }
 
+// Evaluation is the outcome of driving one artifact: the rules evaluated
+// against it, whether or not they matched, and the findings they produced.
+type Evaluation struct {
+	Target   string
+	Rules    []entities.BaseRule
+	Findings []entities.Finding
+}
+
 type Engine struct {
 	rules   []entities.BaseRule
 	runners map[string]Runner
@@ -49,34 +70,100 @@ func New(rules []entities.BaseRule, runners ...Runner) *Engine {
 }
 
 func (e *Engine) Run(ctx context.Context, driver Driver) ([]entities.Finding, error) {
+	evaluation, err := e.Evaluate(ctx, driver)
+	return evaluation.Findings, err
+}
+
+// Evaluate drives one artifact like Run and also reports which rules were
+// evaluated, so that passing controls can be listed next to the findings.
+func (e *Engine) Evaluate(ctx context.Context, driver Driver) (Evaluation, error) {
+	evaluation := Evaluation{Target: driver.Target()}
 	runner, ok := e.runners[strings.ToLower(driver.Target())]
 	if !ok {
-		return nil, nil
+		return evaluation, nil
+	}
+
+	evaluated := make([]bool, len(e.rules))
+	var fileSuppressions []entities.Suppression
+	finish := func(findings []entities.Finding) Evaluation {
+		// File-wide directives may appear after the findings they cover.
+		applySuppressions(findings, fileSuppressions)
+		for i, rule := range e.rules {
+			if evaluated[i] {
+				evaluation.Rules = append(evaluation.Rules, rule)
+			}
+		}
+		evaluation.Findings = findings
+		return evaluation
 	}
 
 	var findings []entities.Finding
+	occurrences := map[string]int{}
 	for {
 		step, hasNext, err := driver.Next(ctx)
 		if err != nil {
-			return findings, err
+			return finish(findings), err
 		}
 		if !hasNext {
 			break
 		}
+		scope, anchor := fingerprintAnchor(step)
+		key := strings.ToLower(step.Subject) + "\x00" + scope + "\x00" + entities.NormalizeCode(anchor)
+		step.Occurrence = occurrences[key]
+		occurrences[key]++
+
+		var stepSuppressions []entities.Suppression
+		for _, suppression := range append(ParseSuppressions(step.Comments), step.Suppressions...) {
+			if suppression.Scope == entities.SuppressionScopeFile {
+				fileSuppressions = append(fileSuppressions, suppression)
+				continue
+			}
+			stepSuppressions = append(stepSuppressions, suppression)
+		}
 
-		findings = append(findings, e.evalPhase(ctx, runner, driver, step, "pre")...)
+		stepFindings := e.evalPhase(ctx, runner, driver, step, "pre", evaluated)
 		if err := driver.Transfer(ctx, step); err != nil {
-			return findings, err
+			applySuppressions(stepFindings, stepSuppressions)
+			return finish(append(findings, stepFindings...)), err
 		}
-		findings = append(findings, e.evalPhase(ctx, runner, driver, step, "post")...)
+		stepFindings = append(stepFindings, e.evalPhase(ctx, runner, driver, step, "post", evaluated)...)
+		applySuppressions(stepFindings, stepSuppressions)
+		findings = append(findings, stepFindings...)
 	}
 
+	return finish(findings), nil
+}
+
+// RunAll runs every driver with the runner registered for its target and
+// concatenates the findings in driver order.
+func (e *Engine) RunAll(ctx context.Context, drivers ...Driver) ([]entities.Finding, error) {
+	var findings []entities.Finding
+	for _, driver := range drivers {
+		driverFindings, err := e.Run(ctx, driver)
+		findings = append(findings, driverFindings...)
+		if err != nil {
+			return findings, err
+		}
+	}
 	return findings, nil
 }
 
-func (e *Engine) evalPhase(ctx context.Context, runner Runner, driver Driver, step Step, phase string) []entities.Finding {
+// EvaluateAll evaluates every driver in order, one Evaluation per driver.
+func (e *Engine) EvaluateAll(ctx context.Context, drivers ...Driver) ([]Evaluation, error) {
+	evaluations := make([]Evaluation, 0, len(drivers))
+	for _, driver := range drivers {
+		evaluation, err := e.Evaluate(ctx, driver)
+		evaluations = append(evaluations, evaluation)
+		if err != nil {
+			return evaluations, err
+		}
+	}
+	return evaluations, nil
+}
+
+func (e *Engine) evalPhase(ctx context.Context, runner Runner, driver Driver, step Step, phase string, evaluated []bool) []entities.Finding {
 	var findings []entities.Finding
-	for _, rule := range e.rules {
+	for i, rule := range e.rules {
 		if !sameString(rule.Target, step.Target) {
 			continue
 		}
@@ -85,6 +172,7 @@ func (e *Engine) evalPhase(ctx context.Context, runner Runner, driver Driver, st
 			continue
 		}
 
+		evaluated[i] = true
 		findings = append(findings, runner.Eval(ctx, driver.DomainContext(), rule, step)...)
 	}
 	return findings
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+type sliceDriver struct {
+	target string
+	steps  []Step
+}
+
+func (d *sliceDriver) Target() string { return d.target }
+
+func (d *sliceDriver) Next(context.Context) (Step, bool, error) {
+	if len(d.steps) == 0 {
+		return Step{}, false, nil
+	}
+	step := d.steps[0]
+	d.steps = d.steps[1:]
+	return step, true, nil
+}
+
+func (d *sliceDriver) Transfer(context.Context, Step) error { return nil }
+
+func (d *sliceDriver) DomainContext() any { return nil }
+
+type matchAllRunner struct {
+	target string
+}
+
+func (r matchAllRunner) Target() string { return r.target }
+
+func (r matchAllRunner) Eval(_ context.Context, _ any, rule entities.BaseRule, step Step) []entities.Finding {
+	return []entities.Finding{BuildFinding(rule, step)}
+}
+
+func TestRunAllUsesRunnerPerTarget(t *testing.T) {
+	rules := []entities.BaseRule{
+		{Target: "dockerfile", Metadata: &entities.Metadata{ID: "DF001"}},
+		{Target: "compose", Metadata: &entities.Metadata{ID: "CP001"}},
+	}
+	eng := New(rules, matchAllRunner{target: "dockerfile"}, matchAllRunner{target: "compose"})
+
+	findings, err := eng.RunAll(context.Background(),
+		&sliceDriver{target: "dockerfile", steps: []Step{{Target: "dockerfile", Raw: "FROM alpine"}}},
+		&sliceDriver{target: "compose", steps: []Step{{Target: "compose", Path: "services.app"}}},
+		&sliceDriver{target: "helm", steps: []Step{{Target: "helm"}}},
+	)
+	if err != nil {
+		t.Fatalf("RunAll() error = %v", err)
+	}
+	if len(findings) != 2 || findings[0].ID != "DF001" || findings[1].ID != "CP001" {
+		t.Fatalf("findings = %+v, want DF001 then CP001", findings)
+	}
+}
+
+type noMatchRunner struct{}
+
+func (noMatchRunner) Target() string { return "dockerfile" }
+
+func (noMatchRunner) Eval(context.Context, any, entities.BaseRule, Step) []entities.Finding {
+	return nil
+}
+
+func TestEvaluateReportsRulesWithoutFindings(t *testing.T) {
+	rules := []entities.BaseRule{
+		{Target: "dockerfile", Metadata: &entities.Metadata{ID: "DF001"}},
+		{Target: "compose", Metadata: &entities.Metadata{ID: "CP001"}},
+		{Target: "dockerfile", Phase: "pre", Metadata: &entities.Metadata{ID: "DF002"}},
+	}
+	evaluation, err := New(rules, noMatchRunner{}).Evaluate(context.Background(),
+		&sliceDriver{target: "dockerfile", steps: []Step{{Target: "dockerfile", Raw: "FROM alpine"}}},
+	)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if len(evaluation.Findings) != 0 {
+		t.Fatalf("findings = %+v, want none", evaluation.Findings)
+	}
+	if len(evaluation.Rules) != 2 || evaluation.Rules[0].Metadata.ID != "DF001" || evaluation.Rules[1].Metadata.ID != "DF002" {
+		t.Fatalf("rules = %+v, want DF001 and DF002", evaluation.Rules)
+	}
+
+	evaluation, err = New(rules, noMatchRunner{}).Evaluate(context.Background(), &sliceDriver{target: "dockerfile"})
+	if err != nil || len(evaluation.Rules) != 0 {
+		t.Fatalf("Evaluate() = %+v, %v, want no rules for an artifact without steps", evaluation, err)
+	}
+}
+
+// failingDriver fails to transfer its last step.
+type failingDriver struct {
+	sliceDriver
+}
+
+func (d *failingDriver) Transfer(context.Context, Step) error {
+	if len(d.steps) == 0 {
+		return errors.New("transfer failed")
+	}
+	return nil
+}
+
+func TestEvaluateAppliesSuppressionsWhenTransferFails(t *testing.T) {
+	rules := []entities.BaseRule{
+		{Target: "dockerfile", Phase: "pre", Metadata: &entities.Metadata{ID: "DF001"}},
+		{Target: "dockerfile", Phase: "pre", Metadata: &entities.Metadata{ID: "DF002"}},
+	}
+	driver := &failingDriver{sliceDriver{target: "dockerfile", steps: []Step{
+		{Target: "dockerfile", Raw: "FROM alpine", Comments: []string{"# containsentry:ignore-file DF002 reason=\"vendor image\""}},
+		{Target: "dockerfile", Raw: "USER root", Comments: []string{"# containsentry:ignore DF001 reason=\"init step\""}},
+	}}}
+
+	evaluation, err := New(rules, matchAllRunner{target: "dockerfile"}).Evaluate(context.Background(), driver)
+	if err == nil {
+		t.Fatalf("Evaluate() error = nil, want transfer error")
+	}
+	if len(evaluation.Findings) != 4 {
+		t.Fatalf("findings = %+v, want 4", evaluation.Findings)
+	}
+	for _, finding := range evaluation.Findings {
+		want := finding.ID == "DF002" || finding.CodeSample == "USER root"
+		if (finding.Suppression != nil) != want {
+			t.Errorf("%s on %q suppressed = %v, want %v", finding.ID, finding.CodeSample, finding.Suppression != nil, want)
+		}
+	}
+}
+
+func TestEvaluateKeepsRepeatedInstructionsDistinct(t *testing.T) {
+	rules := []entities.BaseRule{{Target: "dockerfile", Metadata: &entities.Metadata{ID: "DF006"}}}
+	step := Step{Target: "dockerfile", Subject: "user", Stage: "runtime", Raw: "USER root"}
+	driver := &sliceDriver{target: "dockerfile", steps: []Step{step, {Target: "dockerfile", Subject: "user", Stage: "runtime", Raw: "USER  root"}, step}}
+
+	findings, err := New(rules, matchAllRunner{target: "dockerfile"}).Run(context.Background(), driver)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		if seen[finding.Fingerprint] {
+			t.Fatalf("repeated instruction shares fingerprint %s", finding.Fingerprint)
+		}
+		seen[finding.Fingerprint] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("findings = %+v, want 3", findings)
+	}
+
+	again, err := New(rules, matchAllRunner{target: "dockerfile"}).Run(context.Background(), &sliceDriver{target: "dockerfile", steps: []Step{step}})
+	if err != nil || len(again) != 1 || again[0].Fingerprint != findings[0].Fingerprint {
+		t.Fatalf("first occurrence fingerprint must be stable: %+v, %v", again, err)
+	}
+}
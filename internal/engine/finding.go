@@ -8,14 +8,44 @@ func BuildFinding(rule entities.BaseRule, step Step) entities.Finding {
 		Location:   step.Location,
 		Target:     step.Target,
 		Subject:    step.Subject,
+		Service:    step.Service,
+		Stage:      step.Stage,
 	}
 	if rule.Metadata != nil {
 		finding.ID = rule.Metadata.ID
 		finding.Name = rule.Metadata.Name
 		finding.Severity = rule.Metadata.Severity
+		finding.OriginalSeverity = rule.Metadata.OriginalSeverity
 		finding.Description = rule.Metadata.Description
 		finding.Mitigation = rule.Metadata.Mitigation
 		finding.Reference = rule.Metadata.Reference
 	}
+	finding.Fingerprint = fingerprint(finding.ID, step)
 	return finding
 }
+
+// fingerprint identifies the finding by its rule and the anchor of the step
+// within its scope, see fingerprintAnchor.
+func fingerprint(ruleID string, step Step) string {
+	scope, anchor := fingerprintAnchor(step)
+	return entities.FindingFingerprint(ruleID, step.Target, step.Subject, scope, anchor, step.Occurrence)
+}
+
+// fingerprintAnchor prefers the driver-provided path as the anchor (Compose
+// field paths) and falls back to the raw instruction (Dockerfile). In scan
+// mode the artifact path is part of the scope, so equal stages or services in
+// different files stay distinct.
+func fingerprintAnchor(step Step) (scope string, anchor string) {
+	scope = step.Stage
+	if step.Service != "" {
+		scope = step.Service
+	}
+	if step.File != "" {
+		scope = step.File + ":" + scope
+	}
+	anchor = step.Raw
+	if step.Path != "" {
+		anchor = step.Path
+	}
+	return scope, anchor
+}
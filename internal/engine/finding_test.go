@@ -15,6 +15,8 @@ func TestBuildFindingEnrichesMetadata(t *testing.T) {
 			Description: "desc",
 			Mitigation:  "fix it",
 			Reference:   "docs",
+
+			OriginalSeverity: "info",
 		},
 	}
 	step := Step{
@@ -34,4 +36,38 @@ func TestBuildFindingEnrichesMetadata(t *testing.T) {
 	if finding.Target != "compose" || finding.Subject != "service" {
 		t.Fatalf("unexpected target/subject: %+v", finding)
 	}
+	if finding.OriginalSeverity != "info" {
+		t.Fatalf("OriginalSeverity = %q, want info", finding.OriginalSeverity)
+	}
+}
+
+func TestBuildFindingFingerprint(t *testing.T) {
+	rule := entities.BaseRule{Metadata: &entities.Metadata{ID: "DF001"}}
+
+	first := BuildFinding(rule, Step{Target: "dockerfile", Stage: "runtime", Raw: "FROM alpine:latest", Location: 1})
+	shifted := BuildFinding(rule, Step{Target: "dockerfile", Stage: "runtime", Raw: "FROM  alpine:latest ", Location: 7})
+	if first.Fingerprint == "" || first.Fingerprint != shifted.Fingerprint {
+		t.Fatalf("fingerprint must survive line and whitespace changes: %q vs %q", first.Fingerprint, shifted.Fingerprint)
+	}
+	otherStage := BuildFinding(rule, Step{Target: "dockerfile", Stage: "builder", Raw: "FROM alpine:latest"})
+	if otherStage.Fingerprint == first.Fingerprint {
+		t.Fatalf("fingerprint must depend on stage")
+	}
+
+	composeRule := entities.BaseRule{Metadata: &entities.Metadata{ID: "CP024"}}
+	ports := BuildFinding(composeRule, Step{Target: "compose", Service: "app", Path: "services.app.ports", Raw: `["8080:80"]`})
+	changedPorts := BuildFinding(composeRule, Step{Target: "compose", Service: "app", Path: "services.app.ports", Raw: `["9090:80"]`})
+	if ports.Fingerprint != changedPorts.Fingerprint {
+		t.Fatalf("compose fingerprint must depend on field path, not value")
+	}
+
+	otherSubject := BuildFinding(rule, Step{Target: "dockerfile", Subject: "from", Stage: "runtime", Raw: "FROM alpine:latest"})
+	if otherSubject.Fingerprint == first.Fingerprint {
+		t.Fatalf("fingerprint must depend on subject")
+	}
+
+	otherFile := BuildFinding(rule, Step{Target: "dockerfile", File: "api/Dockerfile", Stage: "runtime", Raw: "FROM alpine:latest"})
+	if otherFile.Fingerprint == first.Fingerprint {
+		t.Fatalf("fingerprint must depend on the scanned file")
+	}
 }
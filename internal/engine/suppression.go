@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const (
+	directiveIgnore     = "containsentry:ignore"
+	directiveIgnoreFile = "containsentry:ignore-file"
+)
+
+// ParseSuppressions extracts ignore directives from source comments:
+//
+//	# containsentry:ignore DF002 reason="vendor image"
+//	# containsentry:ignore-file DF005,DF022 reason="distroless runtime"
+func ParseSuppressions(comments []string) []entities.Suppression {
+	var suppressions []entities.Suppression
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(comment), "#"))
+		directive, rest, _ := strings.Cut(text, " ")
+
+		var scope string
+		switch strings.ToLower(directive) {
+		case directiveIgnore:
+			scope = entities.SuppressionScopeStep
+		case directiveIgnoreFile:
+			scope = entities.SuppressionScopeFile
+		default:
+			continue
+		}
+
+		rules, reason := parseDirectiveArgs(rest)
+		if len(rules) == 0 {
+			continue
+		}
+		suppressions = append(suppressions, entities.Suppression{
+			Rules:  rules,
+			Reason: reason,
+			Scope:  scope,
+			Source: "comment",
+		})
+	}
+	return suppressions
+}
+
+func parseDirectiveArgs(input string) ([]string, string) {
+	head := input
+	reason := ""
+	if index := strings.Index(strings.ToLower(input), "reason="); index >= 0 {
+		head = input[:index]
+		reason = strings.TrimSpace(input[index+len("reason="):])
+		if len(reason) >= 2 && (reason[0] == '"' || reason[0] == '\'') {
+			if end := strings.IndexByte(reason[1:], reason[0]); end >= 0 {
+				reason = reason[1 : end+1]
+			}
+		}
+	}
+
+	rules := strings.FieldsFunc(head, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	return rules, reason
+}
+
+func applySuppressions(findings []entities.Finding, suppressions []entities.Suppression) {
+	for i := range findings {
+		if findings[i].Suppression != nil {
+			continue
+		}
+		for _, suppression := range suppressions {
+			if suppression.Matches(findings[i].ID) {
+				matched := suppression
+				findings[i].Suppression = &matched
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package engine
+
+import "testing"
+
+func TestParseSuppressions(t *testing.T) {
+	suppressions := ParseSuppressions([]string{
+		"regular comment",
+		`containsentry:ignore DF002 reason="vendor image"`,
+		"# containsentry:ignore-file DF01*, DF022 reason='distroless runtime'",
+		"containsentry:ignore",
+	})
+	if len(suppressions) != 2 {
+		t.Fatalf("suppressions = %d, want 2: %+v", len(suppressions), suppressions)
+	}
+
+	step := suppressions[0]
+	if step.Scope != "step" || step.Reason != "vendor image" || !step.Matches("df002") || step.Matches("DF003") {
+		t.Fatalf("unexpected step suppression: %+v", step)
+	}
+
+	file := suppressions[1]
+	if file.Scope != "file" || file.Reason != "distroless runtime" {
+		t.Fatalf("unexpected file suppression: %+v", file)
+	}
+	if !file.Matches("DF012") || !file.Matches("DF022") || file.Matches("DF005") {
+		t.Fatalf("unexpected file suppression matching: %+v", file)
+	}
+}
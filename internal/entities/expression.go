@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -18,6 +19,22 @@ type Expression interface {
 	MatchCommand(subject string, command any, raw string) bool
 }
 
+// ExpressionKinds lists the values accepted in expr_kind (or its alias kind).
+var ExpressionKinds = []string{"regex", "user_id_compare", "dockerfile_constraint", "dsl", "field"}
+
+// DSLSelects lists the select paths of dsl expressions.
+var DSLSelects = []string{"run.script", "run.mounts"}
+
+// UserIDOperators lists the comparison operators of user_id_compare.
+var UserIDOperators = []string{">", ">=", "<", "<=", "==", "=", "!="}
+
+// ExprNodeOps and MatcherOps list the ops of dsl expressions and of the
+// matchers used by call and mount nodes.
+var (
+	ExprNodeOps = []string{"all", "any", "not", "exists", "pipe", "call", "mount"}
+	MatcherOps  = []string{"eq", "contains", "in", "regex"}
+)
+
 type expressionProbe struct {
 	ExprKind string `json:"expr_kind"`
 	Kind     string `json:"kind"`
@@ -280,14 +297,16 @@ func (e *ExpressionDSL) MatchCommand(subject string, command any, raw string) bo
 		return false
 	}
 
-	facts := buildRunFacts(command, raw)
-	ctx := evalContext{facts: facts}
-	switch strings.ToLower(strings.TrimSpace(e.Select)) {
-	case "run.script", "run.mounts":
-		return e.Expr.eval(ctx)
-	default:
+	if !KnownDSLSelect(e.Select) {
 		return false
 	}
+	return e.Expr.eval(evalContext{facts: buildRunFacts(command, raw)})
+}
+
+// KnownDSLSelect reports whether a dsl expression select path is one of
+// DSLSelects.
+func KnownDSLSelect(selectPath string) bool {
+	return slices.Contains(DSLSelects, strings.ToLower(strings.TrimSpace(selectPath)))
 }
 
 type ExprNode struct {
@@ -479,6 +498,30 @@ type evalContext struct {
 	mount       *MountSpec
 	pipe        *PipeFact
 	selectScope string
+	// hit receives the source text of the fact that made the expression
+	// match. Nodes that evaluate to false leave it unchanged.
+	hit *scriptHit
+}
+
+// scriptHit is the text of a matched fact and its byte offset in the RUN
+// script, or -1 when the offset is unknown.
+type scriptHit struct {
+	text   string
+	offset int
+}
+
+func (ctx evalContext) mark(text string, offset int) {
+	if ctx.hit != nil && text != "" {
+		*ctx.hit = scriptHit{text: text, offset: offset}
+	}
+}
+
+func (ctx evalContext) saveHit() func() {
+	if ctx.hit == nil {
+		return func() {}
+	}
+	saved := *ctx.hit
+	return func() { *ctx.hit = saved }
 }
 
 func (n *ExprNode) eval(ctx evalContext) bool {
@@ -488,8 +531,10 @@ func (n *ExprNode) eval(ctx evalContext) bool {
 
 	switch n.Op {
 	case "all":
+		restore := ctx.saveHit()
 		for _, child := range n.Args {
 			if !child.eval(ctx) {
+				restore()
 				return false
 			}
 		}
@@ -505,6 +550,7 @@ func (n *ExprNode) eval(ctx evalContext) bool {
 		if n.Arg == nil {
 			return false
 		}
+		defer ctx.saveHit()()
 		return !n.Arg.eval(ctx)
 	case "exists":
 		if n.Where == nil {
@@ -546,38 +592,40 @@ func (n *ExprNode) eval(ctx evalContext) bool {
 		}
 	case "call":
 		if ctx.call != nil {
-			return n.matchCall(*ctx.call)
+			if n.matchCall(*ctx.call) {
+				ctx.mark(ctx.call.Text, ctx.call.Offset)
+				return true
+			}
+			return false
 		}
 		for _, call := range ctx.facts.Calls {
 			if n.matchCall(call) {
+				ctx.mark(call.Text, call.Offset)
 				return true
 			}
 		}
 		return false
 	case "pipe":
 		if ctx.pipe != nil {
-			left := ctx
-			left.call = &ctx.pipe.First
-			right := ctx
-			right.call = &ctx.pipe.Last
-			return n.Left != nil && n.Right != nil && n.Left.eval(left) && n.Right.eval(right)
+			return n.matchPipe(ctx, *ctx.pipe)
 		}
 		for _, pipe := range ctx.facts.Pipes {
-			left := ctx
-			right := ctx
-			left.call = &pipe.First
-			right.call = &pipe.Last
-			if n.Left != nil && n.Right != nil && n.Left.eval(left) && n.Right.eval(right) {
+			if n.matchPipe(ctx, pipe) {
 				return true
 			}
 		}
 		return false
 	case "mount":
 		if ctx.mount != nil {
-			return n.matchMount(*ctx.mount)
+			if n.matchMount(*ctx.mount) {
+				ctx.mark("--mount="+ctx.mount.Raw, -1)
+				return true
+			}
+			return false
 		}
 		for _, mount := range ctx.facts.Mounts {
 			if n.matchMount(mount) {
+				ctx.mark("--mount="+mount.Raw, -1)
 				return true
 			}
 		}
@@ -587,6 +635,25 @@ func (n *ExprNode) eval(ctx evalContext) bool {
 	}
 }
 
+// matchPipe highlights the producing side of a pipe, e.g. the curl in
+// `curl ... | sh`.
+func (n *ExprNode) matchPipe(ctx evalContext, pipe PipeFact) bool {
+	if n.Left == nil || n.Right == nil {
+		return false
+	}
+	restore := ctx.saveHit()
+	left := ctx
+	left.call = &pipe.First
+	right := ctx
+	right.call = &pipe.Last
+	if !n.Left.eval(left) || !n.Right.eval(right) {
+		restore()
+		return false
+	}
+	ctx.mark(pipe.First.Text, pipe.First.Offset)
+	return true
+}
+
 func (n *ExprNode) matchCall(call CallFact) bool {
 	if n.Name != nil && !n.Name.Match(call.Name) {
 		return false
@@ -664,6 +731,11 @@ type RunFacts struct {
 type CallFact struct {
 	Name string
 	Args []string
+	// Text is the call as written in the script.
+	Text string
+	// Offset is the byte offset of Text in the RUN script, or -1 for calls
+	// of a nested `sh -c` script.
+	Offset int
 }
 
 type PipeFact struct {
@@ -706,11 +778,18 @@ func collectScriptFacts(script string) ([]CallFact, []PipeFact) {
 	syntax.Walk(parsed, func(node syntax.Node) bool {
 		switch current := node.(type) {
 		case *syntax.CallExpr:
-			if call, ok := callFromExpr(current); ok {
+			if call, ok := callFromExpr(script, current); ok {
 				calls = append(calls, call)
 			}
 			if nestedScript, ok := nestedShellScript(current); ok {
 				nestedCalls, nestedPipes := collectScriptFacts(nestedScript)
+				for i := range nestedCalls {
+					nestedCalls[i].Offset = -1
+				}
+				for i := range nestedPipes {
+					nestedPipes[i].First.Offset = -1
+					nestedPipes[i].Last.Offset = -1
+				}
 				calls = append(calls, nestedCalls...)
 				pipes = append(pipes, nestedPipes...)
 			}
@@ -718,8 +797,8 @@ func collectScriptFacts(script string) ([]CallFact, []PipeFact) {
 			if current.Op != syntax.Pipe {
 				return true
 			}
-			leftCalls := collectCallsFromNode(current.X)
-			rightCalls := collectCallsFromNode(current.Y)
+			leftCalls := collectCallsFromNode(script, current.X)
+			rightCalls := collectCallsFromNode(script, current.Y)
 			if len(leftCalls) == 0 || len(rightCalls) == 0 {
 				return true
 			}
@@ -731,14 +810,14 @@ func collectScriptFacts(script string) ([]CallFact, []PipeFact) {
 	return calls, pipes
 }
 
-func collectCallsFromNode(node syntax.Node) []CallFact {
+func collectCallsFromNode(script string, node syntax.Node) []CallFact {
 	if node == nil {
 		return nil
 	}
 	calls := make([]CallFact, 0)
 	syntax.Walk(node, func(current syntax.Node) bool {
 		if callExpr, ok := current.(*syntax.CallExpr); ok {
-			if call, ok := callFromExpr(callExpr); ok {
+			if call, ok := callFromExpr(script, callExpr); ok {
 				calls = append(calls, call)
 			}
 		}
@@ -747,7 +826,7 @@ func collectCallsFromNode(node syntax.Node) []CallFact {
 	return calls
 }
 
-func callFromExpr(callExpr *syntax.CallExpr) (CallFact, bool) {
+func callFromExpr(script string, callExpr *syntax.CallExpr) (CallFact, bool) {
 	if callExpr == nil || len(callExpr.Args) == 0 {
 		return CallFact{}, false
 	}
@@ -766,7 +845,15 @@ func callFromExpr(callExpr *syntax.CallExpr) (CallFact, bool) {
 		args = append(args, strings.ToLower(arg))
 	}
 
-	return CallFact{Name: strings.ToLower(name), Args: args}, true
+	return CallFact{Name: strings.ToLower(name), Args: args, Text: nodeText(script, callExpr), Offset: int(callExpr.Pos().Offset())}, true
+}
+
+func nodeText(script string, node syntax.Node) string {
+	start, end := int(node.Pos().Offset()), int(node.End().Offset())
+	if start < 0 || end > len(script) || start >= end {
+		return ""
+	}
+	return script[start:end]
 }
 
 func wordToString(word *syntax.Word) string {
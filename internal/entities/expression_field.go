@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ExpressionField struct {
@@ -35,6 +36,13 @@ func (e *ExpressionField) Evaluate(value any, present bool, resolver FieldResolv
 	return e.Expr.Eval(FieldInput{Value: value, Present: present, Resolve: resolver})
 }
 
+// FieldExprNodeOps lists the ops of field expressions.
+var FieldExprNodeOps = []string{"all", "any", "not", "field", "len", "any_item", "all_items", "none_item", "regex", "exists", "eq", "ne", "contains", "in", "gt", "gte", "lt", "lte"}
+
+// itemSelect is the select path of the list element bound by any_item,
+// all_items and none_item; item.<key> selects a field of the element.
+const itemSelect = "item"
+
 type FieldResolver func(path string) (any, bool)
 
 type FieldInput struct {
@@ -95,9 +103,9 @@ func (n *FieldExprNode) UnmarshalJSON(data []byte) error {
 			}
 			n.Args = append(n.Args, child)
 		}
-	case "not":
+	case "not", "len", "any_item", "all_items", "none_item":
 		if len(aux.Arg) == 0 {
-			return fmt.Errorf("op not requires arg")
+			return fmt.Errorf("op %s requires arg", n.Op)
 		}
 		n.Arg = &FieldExprNode{}
 		if err := json.Unmarshal(aux.Arg, n.Arg); err != nil {
@@ -120,6 +128,10 @@ func (n *FieldExprNode) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("compile regex pattern %q: %w", n.Pattern, err)
 		}
 		n.compiled = rgx
+	case "gt", "gte", "lt", "lte":
+		if _, ok := ParseQuantity(n.Value); !ok {
+			return fmt.Errorf("op %s requires a number, duration or byte size value, got %v", n.Op, n.Value)
+		}
 	case "exists", "eq", "ne", "contains", "in":
 	default:
 		return fmt.Errorf("unknown field node op %q", n.Op)
@@ -149,6 +161,21 @@ func (n *FieldExprNode) Eval(input FieldInput) bool {
 			}
 		}
 		return false
+	case "gt", "gte", "lt", "lte":
+		cmp, ok := compareQuantity(rangeBound(input.Value, n.Op), n.Value)
+		if !ok {
+			return false
+		}
+		switch n.Op {
+		case "gt":
+			return cmp > 0
+		case "gte":
+			return cmp >= 0
+		case "lt":
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
 	case "regex":
 		for _, item := range stringifyCandidates(input.Value) {
 			if n.ensureCompiled().MatchString(item) {
@@ -175,6 +202,34 @@ func (n *FieldExprNode) Eval(input FieldInput) bool {
 			return false
 		}
 		return !n.Arg.Eval(input)
+	case "len":
+		if n.Arg == nil {
+			return false
+		}
+		return n.Arg.Eval(FieldInput{
+			Value:   lengthOf(input.Value),
+			Present: input.Present,
+			Resolve: input.Resolve,
+		})
+	case "any_item", "all_items", "none_item":
+		if n.Arg == nil {
+			return false
+		}
+		items, _ := listCandidates(input.Value)
+		matched := 0
+		for _, item := range items {
+			if n.Arg.Eval(itemInput(item, input.Resolve)) {
+				matched++
+			}
+		}
+		switch n.Op {
+		case "any_item":
+			return matched > 0
+		case "all_items":
+			return len(items) > 0 && matched == len(items)
+		default:
+			return matched == 0
+		}
 	case "field":
 		if n.Arg == nil || input.Resolve == nil {
 			return false
@@ -190,6 +245,53 @@ func (n *FieldExprNode) Eval(input FieldInput) bool {
 	}
 }
 
+// itemInput binds one list element: item and item.<key> resolve inside it,
+// every other path through the enclosing resolver.
+func itemInput(item any, outer FieldResolver) FieldInput {
+	resolve := func(path string) (any, bool) {
+		if path == itemSelect {
+			return item, true
+		}
+		if rest, ok := strings.CutPrefix(path, itemSelect+"."); ok {
+			return lookupItemField(item, rest)
+		}
+		if outer == nil {
+			return nil, false
+		}
+		return outer(path)
+	}
+	return FieldInput{Value: item, Present: true, Resolve: resolve}
+}
+
+// lookupItemField walks a dotted path through an element by its JSON field
+// names, such as host_ip or bind.propagation.
+func lookupItemField(item any, path string) (any, bool) {
+	current := item
+	for _, key := range strings.Split(path, ".") {
+		fields, ok := derefValue(current).(map[string]any)
+		if !ok {
+			raw, err := json.Marshal(current)
+			if err != nil || json.Unmarshal(raw, &fields) != nil || fields == nil {
+				return nil, false
+			}
+		}
+		value, ok := fields[key]
+		if !ok {
+			for name, candidate := range fields {
+				if strings.EqualFold(name, key) {
+					value, ok = candidate, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
+
 func (n *FieldExprNode) ensureCompiled() *regexp.Regexp {
 	if n.compiled != nil {
 		return n.compiled
@@ -390,3 +492,168 @@ func derefValue(value any) any {
 	}
 	return rv.Interface()
 }
+
+// byteSize matches Docker byte sizes such as 512m, 2g or 1.5GiB. Units are
+// binary, as in the Docker CLI.
+var byteSize = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([kmgtp]?)(?:i?b)?$`)
+
+var byteUnits = map[string]float64{"": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40, "p": 1 << 50}
+
+// portRange matches a port range such as 8000-8010.
+var portRange = regexp.MustCompile(`^(\d+)\s*-\s*(\d+)$`)
+
+// quantityParsers interpret strings, in order of preference.
+var quantityParsers = []func(string) (float64, bool){parseNumber, parseDuration, parseByteSize}
+
+// ParseQuantity reads the value of a comparison op: a number, a duration (in
+// nanoseconds) or a byte size.
+func ParseQuantity(value any) (float64, bool) {
+	return quantityOf(value, quantityParsers)
+}
+
+func quantityOf(value any, parsers []func(string) (float64, bool)) (float64, bool) {
+	if number, ok := numericValue(value); ok {
+		return number, true
+	}
+	text, ok := derefValue(value).(string)
+	if !ok {
+		return 0, false
+	}
+	for _, parse := range parsers {
+		if number, ok := parse(text); ok {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// compareQuantity compares a field value with the value of a rule. Compose
+// decodes durations and byte sizes into numbers, so the rule value is read in
+// their unit; two strings are compared by the first interpretation both have.
+func compareQuantity(actual any, expected any) (int, bool) {
+	actual = derefValue(actual)
+	if text, ok := actual.(string); ok {
+		if other, ok := derefValue(expected).(string); ok {
+			for _, parse := range quantityParsers {
+				a, aok := parse(text)
+				e, eok := parse(other)
+				if aok && eok {
+					return compareFloat(a, e), true
+				}
+			}
+			return 0, false
+		}
+		a, aok := ParseQuantity(text)
+		e, eok := numericValue(expected)
+		return compareFloat(a, e), aok && eok
+	}
+
+	a, ok := numericValue(actual)
+	if !ok {
+		return 0, false
+	}
+	parsers := []func(string) (float64, bool){parseNumber, parseByteSize}
+	if isDuration(actual) {
+		parsers = []func(string) (float64, bool){parseNumber, parseDuration}
+	}
+	e, ok := quantityOf(expected, parsers)
+	return compareFloat(a, e), ok
+}
+
+// rangeBound reduces a port range such as "8000-8010", as Compose keeps a
+// published port, to the bound that decides a comparison: the range matches
+// when any of its ports does, so lt and lte read the lower bound and gt and
+// gte the upper one. Other values are returned unchanged.
+func rangeBound(value any, op string) any {
+	text, ok := derefValue(value).(string)
+	if !ok {
+		return value
+	}
+	match := portRange.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return value
+	}
+	if op == "lt" || op == "lte" {
+		return match[1]
+	}
+	return match[2]
+}
+
+// isDuration recognizes time.Duration and named duration types such as the
+// Compose one by their String form.
+func isDuration(value any) bool {
+	stringer, ok := value.(fmt.Stringer)
+	if !ok {
+		return false
+	}
+	_, err := time.ParseDuration(stringer.String())
+	return err == nil
+}
+
+func compareFloat(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// numericValue reads any integer or float, including named types such as
+// Compose durations and byte sizes.
+func numericValue(value any) (float64, bool) {
+	value = derefValue(value)
+	if value == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func parseNumber(text string) (float64, bool) {
+	number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	return number, err == nil
+}
+
+func parseDuration(text string) (float64, bool) {
+	duration, err := time.ParseDuration(strings.TrimSpace(text))
+	return float64(duration), err == nil
+}
+
+func parseByteSize(text string) (float64, bool) {
+	match := byteSize.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	return number * byteUnits[strings.ToLower(match[2])], err == nil
+}
+
+// lengthOf counts the items of a list or map and the characters of a string;
+// anything else has no length.
+func lengthOf(value any) int {
+	value = derefValue(value)
+	if value == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	case reflect.String:
+		return len([]rune(rv.String()))
+	default:
+		return 0
+	}
+}
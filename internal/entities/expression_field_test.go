@@ -2,9 +2,14 @@ package entities
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
+// byteCount stands in for Compose byte sizes, a named int64.
+type byteCount int64
+
 func TestExpressionFieldEvaluate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -62,6 +67,83 @@ func TestExpressionFieldEvaluate(t *testing.T) {
 			present: true,
 			want:    true,
 		},
+		{
+			name:    "gt compares byte size with decoded bytes",
+			raw:     `{"expr_kind":"field","select":"service.memory_limit","expr":{"op":"gt","value":"2g"}}`,
+			value:   byteCount(3 << 30),
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "lte reads 512m as bytes for decoded bytes",
+			raw:     `{"expr_kind":"field","select":"service.memory_limit","expr":{"op":"lte","value":"512m"}}`,
+			value:   byteCount(512 << 20),
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "lt compares durations",
+			raw:     `{"expr_kind":"field","select":"service.stop_grace_period","expr":{"op":"lt","value":"5s"}}`,
+			value:   2 * time.Second,
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "gte reads 1m as a minute for durations",
+			raw:     `{"expr_kind":"field","select":"service.stop_grace_period","expr":{"op":"gte","value":"1m"}}`,
+			value:   30 * time.Second,
+			present: true,
+			want:    false,
+		},
+		{
+			name:    "lt compares numeric strings",
+			raw:     `{"expr_kind":"field","select":"service.ports","expr":{"op":"lt","value":1024}}`,
+			value:   "80",
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "gt compares float with int",
+			raw:     `{"expr_kind":"field","select":"service.cpu_limit","expr":{"op":"gt","value":2}}`,
+			value:   float32(2.5),
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "gt compares raw byte size strings",
+			raw:     `{"expr_kind":"field","select":"service.memory_limit","expr":{"op":"gt","value":"2g"}}`,
+			value:   "512m",
+			present: true,
+			want:    false,
+		},
+		{
+			name:    "comparison does not match missing value",
+			raw:     `{"expr_kind":"field","select":"service.memory_limit","expr":{"op":"lt","value":"2g"}}`,
+			value:   nil,
+			present: false,
+			want:    false,
+		},
+		{
+			name:    "len counts list items",
+			raw:     `{"expr_kind":"field","select":"service.cap_add","expr":{"op":"len","arg":{"op":"gte","value":2}}}`,
+			value:   []string{"NET_ADMIN", "SYS_ADMIN"},
+			present: true,
+			want:    true,
+		},
+		{
+			name:    "len counts map entries",
+			raw:     `{"expr_kind":"field","select":"service.environment","expr":{"op":"len","arg":{"op":"eq","value":0}}}`,
+			value:   map[string]*string{"A": nil},
+			present: true,
+			want:    false,
+		},
+		{
+			name:    "len of missing field is zero",
+			raw:     `{"expr_kind":"field","select":"service.cap_drop","expr":{"op":"len","arg":{"op":"eq","value":0}}}`,
+			value:   nil,
+			present: false,
+			want:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -85,3 +167,95 @@ func TestExpressionFieldEvaluate(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldExprNodeRejectsInvalidComparisons(t *testing.T) {
+	for _, raw := range []string{
+		`{"op":"gt","value":"lots"}`,
+		`{"op":"lt"}`,
+		`{"op":"len"}`,
+	} {
+		var node FieldExprNode
+		err := json.Unmarshal([]byte(raw), &node)
+		if err == nil || !strings.Contains(err.Error(), "requires") {
+			t.Errorf("Unmarshal(%s) error = %v", raw, err)
+		}
+	}
+}
+
+func TestFieldExprNodeComparesPortRanges(t *testing.T) {
+	tests := []struct {
+		raw   string
+		value any
+		want  bool
+	}{
+		{raw: `{"op":"lt","value":1024}`, value: "80-8080", want: true},
+		{raw: `{"op":"lt","value":1024}`, value: "8000-8010", want: false},
+		{raw: `{"op":"lte","value":8000}`, value: "8000-8010", want: true},
+		{raw: `{"op":"gt","value":8005}`, value: "8000-8010", want: true},
+		{raw: `{"op":"gte","value":8011}`, value: "8000-8010", want: false},
+		{raw: `{"op":"lt","value":1024}`, value: "443", want: true},
+	}
+
+	for _, tt := range tests {
+		var node FieldExprNode
+		if err := json.Unmarshal([]byte(tt.raw), &node); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.raw, err)
+		}
+		if got := node.Eval(FieldInput{Value: tt.value, Present: true}); got != tt.want {
+			t.Errorf("%s on %v = %v, want %v", tt.raw, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFieldExprNodeListQuantifiers(t *testing.T) {
+	type port struct {
+		HostIP    string `json:"host_ip,omitempty"`
+		Target    uint32 `json:"target"`
+		Published string `json:"published,omitempty"`
+	}
+	ports := []port{
+		{HostIP: "127.0.0.1", Target: 80, Published: "8080"},
+		{Target: 443, Published: "443"},
+	}
+	public := `{"op":"field","select":"item.host_ip","arg":{"op":"not","arg":{"op":"in","values":["127.0.0.1","::1"]}}}`
+
+	tests := []struct {
+		name  string
+		raw   string
+		value any
+		want  bool
+	}{
+		{name: "any_item finds the public port", raw: `{"op":"any_item","arg":` + public + `}`, value: ports, want: true},
+		{name: "all_items requires every port", raw: `{"op":"all_items","arg":` + public + `}`, value: ports, want: false},
+		{name: "none_item passes loopback only", raw: `{"op":"none_item","arg":` + public + `}`, value: ports[:1], want: true},
+		{name: "sub-field comparison", raw: `{"op":"any_item","arg":{"op":"field","select":"item.published","arg":{"op":"lt","value":1024}}}`, value: ports, want: true},
+		{name: "item selects scalar elements", raw: `{"op":"all_items","arg":{"op":"field","select":"item","arg":{"op":"regex","pattern":"^NET_"}}}`, value: []string{"NET_ADMIN", "NET_RAW"}, want: true},
+		{name: "element itself is the value", raw: `{"op":"any_item","arg":{"op":"eq","value":"SYS_ADMIN"}}`, value: []string{"NET_ADMIN", "SYS_ADMIN"}, want: true},
+		{name: "missing sub-field is absent", raw: `{"op":"any_item","arg":{"op":"field","select":"item.mode","arg":{"op":"exists"}}}`, value: ports, want: false},
+		{name: "all_items of an empty list", raw: `{"op":"all_items","arg":{"op":"exists"}}`, value: []string{}, want: false},
+		{name: "none_item of a missing list", raw: `{"op":"none_item","arg":{"op":"exists"}}`, value: nil, want: true},
+		{
+			name:  "outer paths resolve inside items",
+			raw:   `{"op":"any_item","arg":{"op":"all","args":[{"op":"field","select":"item.host_ip","arg":{"op":"eq","value":""}},{"op":"field","select":"service.network_mode","arg":{"op":"ne","value":"host"}}]}}`,
+			value: []map[string]any{{"host_ip": ""}},
+			want:  true,
+		},
+	}
+	resolver := func(path string) (any, bool) {
+		if path == "service.network_mode" {
+			return "bridge", true
+		}
+		return nil, false
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node FieldExprNode
+			if err := json.Unmarshal([]byte(tt.raw), &node); err != nil {
+				t.Fatalf("unmarshal node: %v", err)
+			}
+			if got := node.Eval(FieldInput{Value: tt.value, Present: tt.value != nil, Resolve: resolver}); got != tt.want {
+				t.Fatalf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
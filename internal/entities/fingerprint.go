@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// FindingFingerprint derives a deterministic finding identity from the rule,
+// the target, the subject, the scope (Dockerfile stage or Compose service),
+// an anchor such as a normalized instruction or a field path, and the
+// occurrence of that anchor within the scope, so that repeated instructions
+// stay distinct.
+func FindingFingerprint(ruleID, target, subject, scope, anchor string, occurrence int) string {
+	parts := []string{
+		strings.ToUpper(strings.TrimSpace(ruleID)),
+		strings.ToLower(strings.TrimSpace(target)),
+		strings.ToLower(strings.TrimSpace(subject)),
+		strings.TrimSpace(scope),
+		NormalizeCode(anchor),
+		strconv.Itoa(occurrence),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NormalizeCode collapses whitespace so that reformatting does not change
+// fingerprints.
+func NormalizeCode(code string) string {
+	return strings.Join(strings.Fields(code), " ")
+}
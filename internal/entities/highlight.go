@@ -0,0 +1,60 @@
+package entities
+
+import "strings"
+
+// Highlighter is implemented by expressions that can name the part of a
+// matched instruction responsible for the finding, so locations can point at
+// it rather than at the whole instruction.
+type Highlighter interface {
+	// Highlight returns the matched text and its byte offset in raw. The
+	// offset is -1 when it is unknown, e.g. for text from a nested script.
+	Highlight(subject string, command any, raw string) (string, int)
+}
+
+// Highlight returns the first capture group of the first matching pattern, or
+// the whole match when the pattern has no groups.
+func (r *ExpressionRegex) Highlight(_ string, _ any, raw string) (string, int) {
+	r.ensureCompiled()
+	for _, expression := range r.compiled {
+		match := expression.FindStringSubmatchIndex(raw)
+		if match == nil {
+			continue
+		}
+		if len(match) > 3 && match[2] >= 0 && match[3] > match[2] {
+			return raw[match[2]:match[3]], match[2]
+		}
+		return raw[match[0]:match[1]], match[0]
+	}
+	return "", -1
+}
+
+// Highlight returns the user specification, e.g. `root` or `0:0`.
+func (e *ExpressionUserIDCompare) Highlight(_ string, _ any, raw string) (string, int) {
+	fields := strings.Fields(raw)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "USER") {
+		return "", -1
+	}
+	keyword := strings.Index(raw, fields[0]) + len(fields[0])
+	return fields[1], keyword + strings.Index(raw[keyword:], fields[1])
+}
+
+// Highlight returns the call, pipe producer or mount flag that satisfied the
+// expression.
+func (e *ExpressionDSL) Highlight(subject string, command any, raw string) (string, int) {
+	if !strings.EqualFold(strings.TrimSpace(subject), "run") || e.Expr == nil {
+		return "", -1
+	}
+	hit := scriptHit{offset: -1}
+	ctx := evalContext{facts: buildRunFacts(command, raw), hit: &hit}
+	if !e.Expr.eval(ctx) {
+		return "", -1
+	}
+	if hit.offset < 0 {
+		return hit.text, -1
+	}
+	script := strings.Index(raw, extractRunScript(command, raw))
+	if script < 0 {
+		return hit.text, -1
+	}
+	return hit.text, script + hit.offset
+}
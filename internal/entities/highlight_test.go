@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExpressionHighlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		subject string
+		raw     string
+		want    string
+		offset  int
+	}{
+		{
+			name:    "regex capture group",
+			expr:    `{"expr_kind":"regex","expressions":["(?i)^FROM\\s+\\S+(:latest)(?:\\s|$)"]}`,
+			subject: "from",
+			raw:     "FROM alpine:latest AS build",
+			want:    ":latest",
+			offset:  11,
+		},
+		{
+			name:    "regex whole match",
+			expr:    `{"expr_kind":"regex","expressions":["(?i)^ADD\\s+https?://"]}`,
+			subject: "add",
+			raw:     "ADD https://example.com/app.tgz /app",
+			want:    "ADD https://",
+			offset:  0,
+		},
+		{
+			name:    "regex repeated token",
+			expr:    `{"expr_kind":"regex","expressions":["(?i)^USER\\s+\\S+:(0)$"]}`,
+			subject: "user",
+			raw:     "USER 10:0",
+			want:    "0",
+			offset:  8,
+		},
+		{
+			name:    "user spec",
+			expr:    `{"expr_kind":"user_id_compare","operator":"<=","value":1000}`,
+			subject: "user",
+			raw:     "USER 0:0",
+			want:    "0:0",
+			offset:  5,
+		},
+		{
+			name:    "pipe producer",
+			expr:    `{"expr_kind":"dsl","select":"run.script","expr":{"op":"pipe","left":{"op":"call","name":{"op":"in","values":["curl","wget"]}},"right":{"op":"call","name":{"op":"in","values":["sh","bash"]}}}}`,
+			subject: "run",
+			raw:     "RUN apk add curl && curl -fsSL https://example.com/i.sh | sh",
+			want:    "curl -fsSL https://example.com/i.sh",
+			offset:  20,
+		},
+		{
+			name:    "negated branch does not highlight",
+			expr:    `{"expr_kind":"dsl","select":"run.script","expr":{"op":"all","args":[{"op":"exists","where":{"op":"call","name":{"op":"eq","value":"wget"}}},{"op":"not","arg":{"op":"exists","where":{"op":"call","name":{"op":"eq","value":"sha256sum"}}}}]}}`,
+			subject: "run",
+			raw:     "RUN wget -q https://example.com/app.tgz && tar xzf app.tgz",
+			want:    "wget -q https://example.com/app.tgz",
+			offset:  4,
+		},
+		{
+			name:    "mount flag",
+			expr:    `{"expr_kind":"dsl","select":"run.mounts","expr":{"op":"exists","where":{"op":"mount","type":"cache","missing":["id"]}}}`,
+			subject: "run",
+			raw:     "RUN --mount=type=cache,target=/root/.cache go build ./...",
+			want:    "--mount=type=cache,target=/root/.cache",
+			offset:  -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := UnmarshalExpression(json.RawMessage(tt.expr))
+			if err != nil {
+				t.Fatalf("UnmarshalExpression() error = %v", err)
+			}
+			if !expression.MatchCommand(tt.subject, nil, tt.raw) {
+				t.Fatalf("MatchCommand() = false, want true")
+			}
+			highlighter, ok := expression.(Highlighter)
+			if !ok {
+				t.Fatalf("%T does not implement Highlighter", expression)
+			}
+			if got, offset := highlighter.Highlight(tt.subject, nil, tt.raw); got != tt.want || offset != tt.offset {
+				t.Fatalf("Highlight() = %q at %d, want %q at %d", got, offset, tt.want, tt.offset)
+			}
+		})
+	}
+}
@@ -11,6 +11,15 @@ type BaseRule struct {
 	Subject    string     `json:"subject"`
 	Expression Expression `json:"expression"`
 	Metadata   *Metadata  `json:"metadata,omitempty"`
+	Examples   *Examples  `json:"examples,omitempty"`
+}
+
+// Examples are snippets of the rule target, a Dockerfile or Compose YAML,
+// that the rule must report (Match) or must not report (NoMatch). They are
+// run by `containsentry rules test`.
+type Examples struct {
+	Match   []string `json:"match,omitempty"`
+	NoMatch []string `json:"no_match,omitempty"`
 }
 
 type baseRuleAlias struct {
@@ -19,6 +28,7 @@ type baseRuleAlias struct {
 	Subject    string          `json:"subject"`
 	Expression json.RawMessage `json:"expression"`
 	Metadata   *Metadata       `json:"metadata,omitempty"`
+	Examples   *Examples       `json:"examples,omitempty"`
 }
 
 func (r *BaseRule) UnmarshalJSON(data []byte) error {
@@ -37,6 +47,7 @@ func (r *BaseRule) UnmarshalJSON(data []byte) error {
 	r.Subject = aux.Subject
 	r.Expression = expr
 	r.Metadata = aux.Metadata
+	r.Examples = aux.Examples
 	return nil
 }
 
@@ -59,23 +70,33 @@ const (
 )
 
 type Metadata struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	Severity    string `json:"severity,omitempty"`
-	Mitigation  string `json:"mitigation,omitempty"`
-	Reference   string `json:"reference,omitempty"`
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Mitigation  string   `json:"mitigation,omitempty"`
+	Reference   string   `json:"reference,omitempty"`
+
+	// OriginalSeverity is set when a policy overrides the rule file severity.
+	OriginalSeverity string `json:"-"`
 }
 
 type Finding struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Severity    string `json:"severity,omitempty"`
-	Description string `json:"description,omitempty"`
-	Mitigation  string `json:"mitigation,omitempty"`
-	Reference   string `json:"reference,omitempty"`
-	CodeSample  string `json:"code_sample,omitempty"`
-	Location    any    `json:"location,omitempty"`
-	Target      string `json:"target,omitempty"`
-	Subject     string `json:"subject,omitempty"`
+	ID               string `json:"id,omitempty"`
+	Fingerprint      string `json:"fingerprint,omitempty"`
+	Name             string `json:"name,omitempty"`
+	Severity         string `json:"severity,omitempty"`
+	OriginalSeverity string `json:"original_severity,omitempty"`
+	Description      string `json:"description,omitempty"`
+	Mitigation       string `json:"mitigation,omitempty"`
+	Reference        string `json:"reference,omitempty"`
+	CodeSample       string `json:"code_sample,omitempty"`
+	Location         any    `json:"location,omitempty"`
+	Target           string `json:"target,omitempty"`
+	Subject          string `json:"subject,omitempty"`
+	Service          string `json:"service,omitempty"`
+	Stage            string `json:"stage,omitempty"`
+
+	Suppression *Suppression `json:"suppression,omitempty"`
 }
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is an ordered view of the rule severity strings used in rule files.
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityFail
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityFail:
+		return "fail"
+	default:
+		return "none"
+	}
+}
+
+// ParseSeverity orders a finding severity. Unknown or empty values rank as
+// info so that they still count for the "any" threshold.
+func ParseSeverity(value string) Severity {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "fail", "error", "critical", "high":
+		return SeverityFail
+	case "warn", "warning", "medium":
+		return SeverityWarn
+	default:
+		return SeverityInfo
+	}
+}
+
+// ParseThreshold parses a gating threshold such as "fail", "warn" or "any".
+// "none" disables the gate.
+func ParseThreshold(value string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none", "never", "off":
+		return SeverityNone, nil
+	case "any", "info", "note":
+		return SeverityInfo, nil
+	case "warn", "warning":
+		return SeverityWarn, nil
+	case "fail", "error":
+		return SeverityFail, nil
+	default:
+		return SeverityNone, fmt.Errorf("unknown severity threshold %q: want none, any, warn or fail", value)
+	}
+}
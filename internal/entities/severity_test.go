@@ -0,0 +1,37 @@
+package entities
+
+import "testing"
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Severity
+	}{
+		{input: "", want: SeverityNone},
+		{input: "none", want: SeverityNone},
+		{input: "any", want: SeverityInfo},
+		{input: "WARN", want: SeverityWarn},
+		{input: "fail", want: SeverityFail},
+	}
+	for _, tt := range tests {
+		got, err := ParseThreshold(tt.input)
+		if err != nil {
+			t.Fatalf("ParseThreshold(%q) error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseThreshold(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+	if _, err := ParseThreshold("critical-ish"); err == nil {
+		t.Fatalf("ParseThreshold() error = nil, want error for unknown threshold")
+	}
+}
+
+func TestParseSeverityOrdering(t *testing.T) {
+	if !(ParseSeverity("fail") > ParseSeverity("warn") && ParseSeverity("warn") > ParseSeverity("custom")) {
+		t.Fatalf("unexpected severity ordering")
+	}
+	if ParseSeverity("") != SeverityInfo {
+		t.Fatalf("empty severity must rank as info")
+	}
+}
@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	SuppressionScopeStep    = "step"
+	SuppressionScopeFile    = "file"
+	SuppressionScopeService = "service"
+	SuppressionScopeProject = "project"
+)
+
+type Suppression struct {
+	Rules  []string `json:"rules,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	Scope  string   `json:"scope,omitempty"`
+	Source string   `json:"source,omitempty"`
+}
+
+// Matches reports whether the suppression covers the rule ID. Rule selectors
+// are case-insensitive and may be globs such as DF01* or *.
+func (s Suppression) Matches(ruleID string) bool {
+	id := strings.ToUpper(strings.TrimSpace(ruleID))
+	for _, selector := range s.Rules {
+		selector = strings.ToUpper(strings.TrimSpace(selector))
+		if selector == "" {
+			continue
+		}
+		if selector == "ALL" || selector == id {
+			return true
+		}
+		if ok, err := path.Match(selector, id); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
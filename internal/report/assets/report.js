@@ -0,0 +1,36 @@
+(function () {
+  document.querySelectorAll("tr.finding").forEach(function (row) {
+    row.addEventListener("click", function (event) {
+      if (event.target.closest("a")) {
+        return;
+      }
+      var details = row.parentElement.querySelector("tr.details");
+      if (details) {
+        details.hidden = !details.hidden;
+        row.setAttribute("aria-expanded", String(!details.hidden));
+      }
+    });
+  });
+
+  document.querySelectorAll("table.sortable").forEach(function (table) {
+    var headers = table.querySelectorAll("th[data-sort]");
+    headers.forEach(function (header) {
+      header.addEventListener("click", function () {
+        var key = header.getAttribute("data-sort");
+        var numeric = header.hasAttribute("data-numeric");
+        var desc = header.classList.contains("asc");
+        headers.forEach(function (h) { h.classList.remove("asc", "desc"); });
+        header.classList.add(desc ? "desc" : "asc");
+
+        var groups = Array.prototype.slice.call(table.tBodies);
+        groups.sort(function (a, b) {
+          var x = a.dataset[key] || "";
+          var y = b.dataset[key] || "";
+          var order = numeric ? Number(x) - Number(y) : x.localeCompare(y, undefined, { numeric: true });
+          return desc ? -order : order;
+        });
+        groups.forEach(function (group) { table.appendChild(group); });
+      });
+    });
+  });
+})();
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const baselineVersion = 2
+
+// Baseline is either a file written by --write-baseline or a saved JSON
+// report; both carry fingerprinted findings.
+type Baseline struct {
+	Version   int             `json:"version"`
+	Findings  []BaselineEntry `json:"findings"`
+	Baselined []BaselineEntry `json:"baselined,omitempty"`
+
+	index map[string]struct{}
+}
+
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	ID          string `json:"id,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	Service     string `json:"service,omitempty"`
+	Stage       string `json:"stage,omitempty"`
+	CodeSample  string `json:"code_sample,omitempty"`
+}
+
+// Fingerprint returns the fingerprint assigned by the engine. Findings built
+// elsewhere get one derived from their scope and normalized code sample.
+func Fingerprint(finding entities.Finding) string {
+	if finding.Fingerprint != "" {
+		return finding.Fingerprint
+	}
+	scope := finding.Stage
+	if finding.Service != "" {
+		scope = finding.Service
+	}
+	return entities.FindingFingerprint(finding.ID, finding.Target, finding.Subject, scope, finding.CodeSample, 0)
+}
+
+func NewBaseline(findings []entities.Finding) Baseline {
+	baseline := Baseline{Version: baselineVersion, Findings: make([]BaselineEntry, 0, len(findings))}
+	seen := map[string]struct{}{}
+	for _, finding := range findings {
+		if finding.Suppression != nil {
+			continue
+		}
+		fingerprint := Fingerprint(finding)
+		if _, ok := seen[fingerprint]; ok {
+			continue
+		}
+		seen[fingerprint] = struct{}{}
+		baseline.Findings = append(baseline.Findings, BaselineEntry{
+			Fingerprint: fingerprint,
+			ID:          finding.ID,
+			Target:      finding.Target,
+			Subject:     finding.Subject,
+			Service:     finding.Service,
+			Stage:       finding.Stage,
+			CodeSample:  finding.CodeSample,
+		})
+	}
+	return baseline
+}
+
+func (b *Baseline) Contains(fingerprint string) bool {
+	if b == nil {
+		return false
+	}
+	if b.index == nil {
+		b.index = make(map[string]struct{}, len(b.Findings)+len(b.Baselined))
+		for _, entry := range append(append([]BaselineEntry{}, b.Findings...), b.Baselined...) {
+			if entry.Fingerprint != "" {
+				b.index[entry.Fingerprint] = struct{}{}
+			}
+		}
+	}
+	_, ok := b.index[fingerprint]
+	return ok
+}
+
+// WithoutBaselined drops the findings recorded in the baseline, as Build does.
+// Suppressed findings are kept so that formats can report them as such.
+func (b *Baseline) WithoutBaselined(findings []entities.Finding) []entities.Finding {
+	if b == nil {
+		return findings
+	}
+	kept := make([]entities.Finding, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Suppression == nil && b.Contains(Fingerprint(finding)) {
+			continue
+		}
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+func LoadBaseline(path string) (*Baseline, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read baseline file %q: %w", path, err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(payload, &baseline); err != nil {
+		return nil, fmt.Errorf("unmarshal baseline file %q: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+func WriteBaseline(path string, baseline Baseline) error {
+	payload, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
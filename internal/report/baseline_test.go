@@ -0,0 +1,72 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestFingerprintIgnoresLinesAndWhitespace(t *testing.T) {
+	original := entities.Finding{
+		ID:         "DF002",
+		Target:     "dockerfile",
+		Subject:    "from",
+		Stage:      "builder",
+		CodeSample: "FROM golang:1.22 AS builder",
+		Location:   dockerfile.SourceRef{Start: dockerfile.Position{Line: 1}},
+	}
+	shifted := original
+	shifted.CodeSample = "FROM   golang:1.22  AS builder "
+	shifted.Location = dockerfile.SourceRef{Start: dockerfile.Position{Line: 12}}
+	if Fingerprint(original) != Fingerprint(shifted) {
+		t.Fatalf("fingerprint changed after line shift")
+	}
+
+	otherStage := original
+	otherStage.Stage = "runtime"
+	if Fingerprint(original) == Fingerprint(otherStage) {
+		t.Fatalf("fingerprint must depend on stage")
+	}
+}
+
+func TestBuildWithBaselineReportsOnlyNewFindings(t *testing.T) {
+	known := entities.Finding{ID: "CP024", Severity: "fail", Target: "compose", Subject: "ports", Service: "app", CodeSample: `["8080:80"]`}
+	fresh := entities.Finding{ID: "CP003", Severity: "warn", Target: "compose", Subject: "privileged", Service: "app", CodeSample: "true"}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := WriteBaseline(path, NewBaseline([]entities.Finding{known})); err != nil {
+		t.Fatalf("WriteBaseline() error = %v", err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+
+	report := Build([]entities.Finding{known, fresh}, WithBaseline(baseline), WithFailOn(entities.SeverityFail))
+	if len(report.Findings) != 1 || report.Findings[0].ID != "CP003" {
+		t.Fatalf("unexpected new findings: %+v", report.Findings)
+	}
+	if report.Summary.Baselined != 1 || len(report.Baselined) != 1 {
+		t.Fatalf("unexpected baselined summary: %+v", report.Summary)
+	}
+	if !report.Summary.Gate.Passed {
+		t.Fatalf("baselined fail finding must not fail the gate: %+v", report.Summary.Gate)
+	}
+}
+
+func TestSavedReportCanBeUsedAsBaseline(t *testing.T) {
+	finding := entities.Finding{ID: "DF001", Fingerprint: "abc", Severity: "fail"}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteJSON(path, Build([]entities.Finding{finding})); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if !baseline.Contains("abc") {
+		t.Fatalf("baseline loaded from report must contain its fingerprints")
+	}
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const checkstyleVersion = "4.3"
+
+type CheckstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []CheckstyleFile `xml:"file"`
+}
+
+type CheckstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []CheckstyleError `xml:"error"`
+}
+
+type CheckstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// BuildCheckstyle groups the active findings of the report by file in order
+// of first appearance.
+func BuildCheckstyle(rep Report) CheckstyleReport {
+	artifacts := targetArtifacts(rep)
+	result := CheckstyleReport{Version: checkstyleVersion}
+	index := map[string]int{}
+	for _, finding := range rep.Findings {
+		file, line := findingFileLine(finding, artifacts)
+		i, ok := index[file]
+		if !ok {
+			i = len(result.Files)
+			index[file] = i
+			result.Files = append(result.Files, CheckstyleFile{Name: file})
+		}
+
+		message := finding.Name
+		if message == "" {
+			message = finding.ID
+		}
+		pos := findingPosition(finding.Location)
+		if pos.Origin != "" {
+			message += " (" + pos.Origin + ")"
+		}
+		if finding.Fingerprint != "" {
+			message += " [" + finding.Fingerprint + "]"
+		}
+		result.Files[i].Errors = append(result.Files[i].Errors, CheckstyleError{
+			Line:     line,
+			Column:   pos.Column,
+			Severity: CheckstyleSeverity(finding.Severity),
+			Message:  message,
+			Source:   "containsentry." + finding.ID,
+		})
+	}
+	return result
+}
+
+// CheckstyleSeverity maps ContainSentry severities onto Checkstyle levels.
+func CheckstyleSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "fail", "error":
+		return "error"
+	case "warn", "warning":
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func MarshalCheckstyle(report CheckstyleReport) ([]byte, error) {
+	payload, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(payload, '\n')...), nil
+}
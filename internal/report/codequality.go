@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CodeQualityIssue is one entry of a GitLab Code Quality report, a subset of
+// the Code Climate issue format.
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+type CodeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeQualityLines `json:"lines"`
+}
+
+type CodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// BuildCodeQuality lists the active findings of the report; suppressed and
+// baselined findings are left out so the merge request widget shows only
+// new problems.
+func BuildCodeQuality(rep Report) []CodeQualityIssue {
+	artifacts := targetArtifacts(rep)
+	issues := make([]CodeQualityIssue, 0, len(rep.Findings))
+	for _, finding := range rep.Findings {
+		file, line := findingFileLine(finding, artifacts)
+		description := finding.Name
+		if description == "" {
+			description = finding.ID
+		}
+		if pos := findingPosition(finding.Location); pos.Origin != "" {
+			description += " (" + pos.Origin + ")"
+		}
+		issues = append(issues, CodeQualityIssue{
+			Description: description,
+			CheckName:   finding.ID,
+			Fingerprint: finding.Fingerprint,
+			Severity:    CodeQualitySeverity(finding.Severity),
+			Location:    CodeQualityLocation{Path: file, Lines: CodeQualityLines{Begin: line}},
+		})
+	}
+	return issues
+}
+
+// CodeQualitySeverity maps ContainSentry severities onto GitLab Code Quality
+// severities.
+func CodeQualitySeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "fail", "error":
+		return "critical"
+	case "warn", "warning":
+		return "major"
+	default:
+		return "info"
+	}
+}
+
+func MarshalCodeQuality(issues []CodeQualityIssue) ([]byte, error) {
+	return json.MarshalIndent(issues, "", "  ")
+}
+
+// targetArtifacts maps each target to the first artifact it analyzed, used
+// for findings whose location does not name a file.
+func targetArtifacts(rep Report) map[string]string {
+	artifacts := map[string]string{}
+	for _, target := range rep.Summary.ByTarget {
+		if len(target.Artifacts) > 0 {
+			artifacts[target.Target] = target.Artifacts[0]
+		}
+	}
+	return artifacts
+}
+
+// findingFileLine returns the display path and 1-based line of a finding.
+// Line-oriented formats require a line, so unknown lines point at the top of
+// the file.
+func findingFileLine(finding ReportFinding, artifacts map[string]string) (string, int) {
+	pos := findingPosition(finding.Location)
+	file := pos.File
+	if file == "" {
+		file = artifacts[strings.ToLower(finding.Target)]
+	}
+	line := pos.Line
+	if line < 1 {
+		line = 1
+	}
+	return displayPath(file), line
+}
@@ -0,0 +1,49 @@
+package report
+
+import "github.com/katvixlab/contain-sentry/internal/entities"
+
+const (
+	ExitCodeOK       = 0
+	ExitCodeError    = 1
+	ExitCodeGateWarn = 2
+	ExitCodeGateFail = 3
+)
+
+type Gate struct {
+	FailOn   string `json:"fail_on"`
+	Highest  string `json:"highest"`
+	Passed   bool   `json:"passed"`
+	ExitCode int    `json:"exit_code"`
+	// Errors counts artifacts that failed to load; they fail the gate with
+	// ExitCodeError unless findings already failed it.
+	Errors int `json:"errors,omitempty"`
+}
+
+// EvaluateGate compares the highest finding severity with the threshold.
+// A failed gate exits with ExitCodeGateFail when a fail finding is present
+// and with ExitCodeGateWarn otherwise.
+func EvaluateGate(findings []ReportFinding, threshold entities.Severity) Gate {
+	highest := entities.SeverityNone
+	for _, finding := range findings {
+		if severity := entities.ParseSeverity(finding.Severity); severity > highest {
+			highest = severity
+		}
+	}
+
+	gate := Gate{
+		FailOn:   threshold.String(),
+		Highest:  highest.String(),
+		Passed:   true,
+		ExitCode: ExitCodeOK,
+	}
+	if threshold == entities.SeverityNone || highest < threshold {
+		return gate
+	}
+
+	gate.Passed = false
+	gate.ExitCode = ExitCodeGateWarn
+	if highest >= entities.SeverityFail {
+		gate.ExitCode = ExitCodeGateFail
+	}
+	return gate
+}
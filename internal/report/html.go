@@ -0,0 +1,280 @@
+package report
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+//go:embed assets/report.html.tmpl assets/report.css assets/report.js
+var htmlAssets embed.FS
+
+const htmlSnippetContext = 2
+
+var htmlTemplate = template.Must(template.New("report.html.tmpl").Funcs(template.FuncMap{
+	"displayPath": displayPath,
+	"isURL": func(value string) bool {
+		return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+	},
+}).ParseFS(htmlAssets, "assets/report.html.tmpl"))
+
+type HTMLOptions struct {
+	// Title defaults to "ContainSentry report".
+	Title string
+	// BaseDir resolves relative artifact paths when reading source snippets;
+	// defaults to the working directory.
+	BaseDir string
+	// Generated is shown in the header when set.
+	Generated time.Time
+}
+
+type htmlPage struct {
+	Title      string
+	Generated  string
+	ToolURI    string
+	Summary    ReportSummary
+	Findings   []htmlFinding
+	Suppressed []htmlFinding
+	Baselined  []htmlFinding
+	Style      template.CSS
+	Script     template.JS
+}
+
+type htmlFinding struct {
+	ReportFinding
+	Rank          int
+	SeverityLabel string
+	SeverityClass string
+	Scope         string
+	LocationLabel string
+	Origin        string
+	Snippet       []htmlLine
+}
+
+type htmlLine struct {
+	Number   int
+	Hit      bool
+	Segments []htmlSegment
+}
+
+type htmlSegment struct {
+	Text  string
+	Class string
+	Mark  bool
+}
+
+// WriteHTML renders a single self-contained page: styles, script and source
+// snippets are inlined so the file can be shared and opened offline.
+func WriteHTML(w io.Writer, rep Report, opts HTMLOptions) error {
+	style, err := htmlAssets.ReadFile("assets/report.css")
+	if err != nil {
+		return err
+	}
+	script, err := htmlAssets.ReadFile("assets/report.js")
+	if err != nil {
+		return err
+	}
+
+	page := htmlPage{
+		Title:   opts.Title,
+		ToolURI: toolInfoURI,
+		Summary: rep.Summary,
+		Style:   template.CSS(style),
+		Script:  template.JS(script),
+	}
+	if page.Title == "" {
+		page.Title = toolName + " report"
+	}
+	if !opts.Generated.IsZero() {
+		page.Generated = opts.Generated.Format(time.RFC1123)
+	}
+
+	sources := &sourceFiles{baseDir: opts.BaseDir, lines: map[string][]string{}}
+	page.Findings = htmlFindings(rep.Findings, sources)
+	page.Suppressed = htmlFindings(rep.Suppressed, sources)
+	page.Baselined = htmlFindings(rep.Baselined, sources)
+	return htmlTemplate.Execute(w, page)
+}
+
+func htmlFindings(findings []ReportFinding, sources *sourceFiles) []htmlFinding {
+	result := make([]htmlFinding, 0, len(findings))
+	for _, finding := range findings {
+		pos := findingPosition(finding.Location)
+		severity := entities.ParseSeverity(finding.Severity)
+		scope := finding.Stage
+		if finding.Service != "" {
+			scope = finding.Service
+		}
+		label := strings.ToLower(strings.TrimSpace(finding.Severity))
+		if label == "" {
+			label = "unknown"
+		}
+		location := positionLabel(pos, finding.Target)
+		if pos.Path != "" {
+			location += " " + pos.Path
+		}
+		result = append(result, htmlFinding{
+			ReportFinding: finding,
+			// Negated so that an ascending sort lists fail first.
+			Rank:          -int(severity),
+			SeverityLabel: label,
+			SeverityClass: severity.String(),
+			Scope:         scope,
+			LocationLabel: location,
+			Origin:        pos.Origin,
+			Snippet:       htmlSnippet(finding, pos, sources),
+		})
+	}
+	return result
+}
+
+// htmlSnippet shows the finding range with a few lines of context from the
+// source file, falling back to the code sample when the file is unreadable.
+func htmlSnippet(finding ReportFinding, pos position, sources *sourceFiles) []htmlLine {
+	yaml := pos.Path != ""
+	lines := sources.get(pos.File)
+	if pos.Line < 1 || pos.Line > len(lines) {
+		sample := strings.TrimRight(finding.CodeSample, "\n")
+		if sample == "" || sample == pos.Path {
+			return nil
+		}
+		var snippet []htmlLine
+		for i, text := range strings.Split(sample, "\n") {
+			number := 0
+			if pos.Line > 0 {
+				number = pos.Line + i
+			}
+			snippet = append(snippet, htmlLine{Number: number, Segments: highlightLine(text, yaml, 0, 0)})
+		}
+		return snippet
+	}
+
+	endLine := pos.EndLine
+	if endLine < pos.Line || endLine > len(lines) {
+		endLine = pos.Line
+	}
+	first := max(1, pos.Line-htmlSnippetContext)
+	last := min(len(lines), endLine+htmlSnippetContext)
+	snippet := make([]htmlLine, 0, last-first+1)
+	for number := first; number <= last; number++ {
+		text := lines[number-1]
+		hit := number >= pos.Line && number <= endLine
+		markFrom, markTo := 0, 0
+		if hit {
+			markFrom, markTo = 0, len([]rune(text))
+			if number == pos.Line && pos.Column > 0 {
+				markFrom = pos.Column - 1
+			}
+			if number == endLine && pos.EndColumn > 0 && !yaml {
+				markTo = pos.EndColumn - 1
+			}
+		}
+		snippet = append(snippet, htmlLine{Number: number, Hit: hit, Segments: highlightLine(text, yaml, markFrom, markTo)})
+	}
+	return snippet
+}
+
+// highlightLine splits a source line into segments with a syntax class and
+// whether the rune range [markFrom, markTo) of the finding covers them.
+func highlightLine(text string, yaml bool, markFrom, markTo int) []htmlSegment {
+	runes := []rune(text)
+	classes := make([]string, len(runes))
+	if yaml {
+		classifyYAML(runes, classes)
+	} else {
+		classifyDockerfile(runes, classes)
+	}
+
+	var segments []htmlSegment
+	for i, r := range runes {
+		mark := i >= markFrom && i < markTo
+		if n := len(segments); n > 0 && segments[n-1].Class == classes[i] && segments[n-1].Mark == mark {
+			segments[n-1].Text += string(r)
+			continue
+		}
+		segments = append(segments, htmlSegment{Text: string(r), Class: classes[i], Mark: mark})
+	}
+	if len(segments) == 0 {
+		// Keep empty lines visible in the gutter.
+		segments = append(segments, htmlSegment{Text: " "})
+	}
+	return segments
+}
+
+func classifyDockerfile(runes []rune, classes []string) {
+	start := 0
+	for start < len(runes) && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	if start < len(runes) && runes[start] == '#' {
+		fill(classes, start, len(runes), "comment")
+		return
+	}
+	end := start
+	for end < len(runes) && unicode.IsUpper(runes[end]) {
+		end++
+	}
+	if end-start > 1 && (end == len(runes) || unicode.IsSpace(runes[end])) {
+		fill(classes, start, end, "kw")
+	}
+}
+
+func classifyYAML(runes []rune, classes []string) {
+	start := 0
+	for start < len(runes) && (unicode.IsSpace(runes[start]) || runes[start] == '-') {
+		start++
+	}
+	for i := start; i < len(runes); i++ {
+		if runes[i] == '#' && (i == 0 || unicode.IsSpace(runes[i-1])) {
+			fill(classes, i, len(runes), "comment")
+			return
+		}
+	}
+	for i := start; i < len(runes); i++ {
+		if runes[i] == '"' || runes[i] == '\'' {
+			return
+		}
+		if runes[i] == ':' && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			fill(classes, start, i, "key")
+			return
+		}
+	}
+}
+
+func fill(classes []string, from, to int, class string) {
+	for i := from; i < to; i++ {
+		classes[i] = class
+	}
+}
+
+// sourceFiles reads and caches the lines of analyzed artifacts.
+type sourceFiles struct {
+	baseDir string
+	lines   map[string][]string
+}
+
+func (s *sourceFiles) get(path string) []string {
+	if path == "" {
+		return nil
+	}
+	if lines, ok := s.lines[path]; ok {
+		return lines
+	}
+	full := path
+	if !filepath.IsAbs(full) && s.baseDir != "" {
+		full = filepath.Join(s.baseDir, full)
+	}
+	var lines []string
+	if content, err := os.ReadFile(full); err == nil {
+		lines = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	}
+	s.lines[path] = lines
+	return lines
+}
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestWriteHTMLIsSelfContained(t *testing.T) {
+	dir := t.TempDir()
+	source := "# syntax=docker/dockerfile:1\nFROM alpine:latest\nRUN echo \"<b>\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(source), 0o644); err != nil {
+		t.Fatalf("write Dockerfile: %v", err)
+	}
+
+	rep := Build([]entities.Finding{
+		{
+			ID: "DF002", Name: "No digest", Severity: "warn", Target: "dockerfile", Stage: "stage-0",
+			CodeSample: "FROM alpine:latest", Mitigation: "Pin the digest.",
+			Location: dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 2, Character: 5}, End: dockerfile.Position{Line: 2, Character: 18}},
+		},
+		{
+			ID: "DF001", Name: "Latest tag", Severity: "fail", Target: "dockerfile", Stage: "stage-0",
+			CodeSample: "FROM alpine:latest", Reference: "https://example.com/DF001",
+			Location: dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 2, Character: 11}, End: dockerfile.Position{Line: 2, Character: 18}},
+		},
+		{
+			ID: "CP003", Name: "Privileged <service>", Severity: "fail", Target: "compose", Service: "api", CodeSample: "true",
+			Location: model.Location{Path: "services.api.privileged", File: "missing.yaml", Line: 3, Column: 5},
+		},
+		{ID: "DF022", Name: "No healthcheck", Severity: "warn", Target: "dockerfile", Suppression: &entities.Suppression{Reason: "orchestrator"}},
+	}, WithTarget("dockerfile", "Dockerfile"), WithTarget("compose", "missing.yaml"), WithFailOn(entities.SeverityFail))
+
+	var out bytes.Buffer
+	err := WriteHTML(&out, rep, HTMLOptions{BaseDir: dir, Generated: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	page := out.String()
+
+	for _, want := range []string{
+		"<title>ContainSentry report</title>",
+		"Generated Fri, 02 Jan 2026 03:04:05 UTC",
+		`<div class="card fail"><div class="value">2</div>`,
+		`<div class="gate failed">Gate failed: fail_on=fail, highest=fail</div>`,
+		`<table class="sortable">`,
+		`<th data-sort="rank" data-numeric>Severity</th>`,
+		// Source lines with context, the matched range marked.
+		`<span class="line hit"><span class="gutter">2</span><span class="kw">FROM</span> alpine<mark>:latest</mark></span>`,
+		`<span class="gutter">3</span><span class="kw">RUN</span> echo &#34;&lt;b&gt;&#34;</span>`,
+		// Missing source files fall back to the code sample.
+		`<span class="gutter">3</span>true</span>`,
+		"Privileged &lt;service&gt;",
+		`<a href="https://example.com/DF001">`,
+		"<dt>Mitigation</dt><dd>Pin the digest.</dd>",
+		`<h2>Suppressed (1)</h2>`,
+		"<dd>orchestrator</dd>",
+		"function (row)",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("HTML report misses %q", want)
+		}
+	}
+	if strings.Contains(page, `src="`) || strings.Contains(page, `rel="stylesheet"`) {
+		t.Fatalf("HTML report references external assets")
+	}
+	df002, df001, cp003 := strings.Index(page, `data-id="DF002"`), strings.Index(page, `data-id="DF001"`), strings.Index(page, `data-id="CP003"`)
+	if df002 < 0 || df002 > df001 || df001 > cp003 {
+		t.Fatalf("findings should keep the report order")
+	}
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/engine"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+// JUnitArtifact is one analyzed artifact together with the rules evaluated
+// against it and the findings they produced.
+type JUnitArtifact struct {
+	Files      []string
+	Evaluation engine.Evaluation
+}
+
+type JUnitOptions struct {
+	// Baseline marks findings already accepted; their testcases pass.
+	Baseline *Baseline
+}
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	Cases      []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// BuildJUnit turns every rule evaluated against an artifact into a testcase.
+// Active fail findings fail the testcase, warn and info findings are listed
+// in system-out of a passing one, and a rule whose findings are all
+// suppressed is skipped.
+func BuildJUnit(artifacts []JUnitArtifact, opts JUnitOptions) JUnitTestSuites {
+	suites := JUnitTestSuites{Name: toolName}
+	for _, artifact := range artifacts {
+		suite := buildJUnitSuite(artifact, opts)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return suites
+}
+
+func buildJUnitSuite(artifact JUnitArtifact, opts JUnitOptions) JUnitTestSuite {
+	evaluation := artifact.Evaluation
+	files := make([]string, 0, len(artifact.Files))
+	for _, file := range artifact.Files {
+		files = append(files, displayPath(file))
+	}
+	name := strings.Join(files, ",")
+	if name == "" {
+		name = evaluation.Target
+	}
+	suite := JUnitTestSuite{
+		Name:       name,
+		Properties: []JUnitProperty{{Name: "target", Value: evaluation.Target}},
+	}
+
+	byRule := map[string][]entities.Finding{}
+	for _, finding := range evaluation.Findings {
+		byRule[finding.ID] = append(byRule[finding.ID], finding)
+	}
+
+	seen := map[string]struct{}{}
+	for _, rule := range evaluation.Rules {
+		id := junitRuleID(rule)
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		testCase := JUnitTestCase{Name: junitCaseName(rule), ClassName: name}
+		var failures, notes []string
+		suppressed := 0
+		for _, finding := range byRule[id] {
+			switch {
+			case finding.Suppression != nil:
+				suppressed++
+				notes = append(notes, "suppressed: "+junitFindingText(finding)+junitSuppressionReason(finding))
+			case opts.Baseline.Contains(Fingerprint(finding)):
+				notes = append(notes, "baselined: "+junitFindingText(finding))
+			case entities.ParseSeverity(finding.Severity) == entities.SeverityFail:
+				failures = append(failures, junitFindingText(finding))
+			default:
+				notes = append(notes, junitFindingText(finding))
+			}
+		}
+
+		switch {
+		case len(failures) > 0:
+			testCase.Failure = &JUnitFailure{
+				Message: fmt.Sprintf("%d finding(s) of severity fail", len(failures)),
+				Type:    entities.SeverityFail.String(),
+				Text:    strings.Join(failures, "\n"),
+			}
+			suite.Failures++
+		case suppressed > 0 && suppressed == len(byRule[id]):
+			testCase.Skipped = &JUnitSkipped{Message: "suppressed"}
+			suite.Skipped++
+		}
+		testCase.SystemOut = strings.Join(notes, "\n")
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Tests = len(suite.Cases)
+	return suite
+}
+
+func junitRuleID(rule entities.BaseRule) string {
+	if rule.Metadata == nil {
+		return ""
+	}
+	return rule.Metadata.ID
+}
+
+func junitCaseName(rule entities.BaseRule) string {
+	if rule.Metadata == nil {
+		return rule.Subject
+	}
+	if rule.Metadata.Name == "" {
+		return rule.Metadata.ID
+	}
+	return rule.Metadata.ID + " " + rule.Metadata.Name
+}
+
+// junitFindingText is one line per finding: severity, location and code.
+func junitFindingText(finding entities.Finding) string {
+	text := "[" + strings.ToLower(finding.Severity) + "]"
+	pos := findingPosition(finding.Location)
+	switch {
+	case pos.File != "" && pos.Line > 0:
+		text += fmt.Sprintf(" %s:%d", displayPath(pos.File), pos.Line)
+	case pos.File != "":
+		text += " " + displayPath(pos.File)
+	}
+	if pos.Path != "" {
+		text += " " + pos.Path
+	}
+	if sample := strings.TrimSpace(finding.CodeSample); sample != "" && sample != pos.Path {
+		text += ": " + sample
+	}
+	return text
+}
+
+func junitSuppressionReason(finding entities.Finding) string {
+	if finding.Suppression == nil || finding.Suppression.Reason == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", finding.Suppression.Reason)
+}
+
+func MarshalJUnit(suites JUnitTestSuites) ([]byte, error) {
+	payload, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(payload, '\n')...), nil
+}
+
+func WriteJUnit(path string, suites JUnitTestSuites) error {
+	payload, err := MarshalJUnit(suites)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
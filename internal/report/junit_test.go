@@ -0,0 +1,69 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/engine"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestBuildJUnitReportsEvaluatedRules(t *testing.T) {
+	rule := func(id, name string) entities.BaseRule {
+		return entities.BaseRule{Target: "dockerfile", Metadata: &entities.Metadata{ID: id, Name: name}}
+	}
+	location := dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 1}, End: dockerfile.Position{Line: 1}}
+	baselined := entities.Finding{ID: "DF006", Severity: "fail", Target: "dockerfile", Stage: "app", CodeSample: "USER root", Location: location}
+	suites := BuildJUnit([]JUnitArtifact{{
+		Files: []string{"Dockerfile"},
+		Evaluation: engine.Evaluation{
+			Target: "dockerfile",
+			Rules:  []entities.BaseRule{rule("DF001", "Latest tag"), rule("DF002", "No digest"), rule("DF003", "Passing"), rule("DF005", "Suppressed"), rule("DF006", "Root user")},
+			Findings: []entities.Finding{
+				{ID: "DF001", Severity: "fail", Target: "dockerfile", CodeSample: "FROM alpine:latest", Location: location},
+				{ID: "DF002", Severity: "warn", Target: "dockerfile", CodeSample: "FROM alpine:latest", Location: location},
+				{ID: "DF005", Severity: "fail", Target: "dockerfile", Suppression: &entities.Suppression{Reason: "runtime image"}},
+				baselined,
+			},
+		},
+	}}, JUnitOptions{Baseline: &Baseline{Findings: []BaselineEntry{{Fingerprint: Fingerprint(baselined)}}}})
+
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Skipped != 1 {
+		t.Fatalf("totals = %d/%d/%d, want 5 tests, 1 failure, 1 skipped", suites.Tests, suites.Failures, suites.Skipped)
+	}
+	cases := suites.Suites[0].Cases
+	if suites.Suites[0].Name != "Dockerfile" || len(cases) != 5 {
+		t.Fatalf("suite = %+v", suites.Suites[0])
+	}
+	if cases[0].Name != "DF001 Latest tag" || cases[0].Failure == nil || cases[0].Failure.Text != "[fail] Dockerfile:1: FROM alpine:latest" {
+		t.Fatalf("DF001 = %+v, want failure", cases[0])
+	}
+	if cases[1].Failure != nil || cases[1].SystemOut != "[warn] Dockerfile:1: FROM alpine:latest" {
+		t.Fatalf("DF002 = %+v, want passing testcase with system-out", cases[1])
+	}
+	if cases[2].Failure != nil || cases[2].Skipped != nil || cases[2].SystemOut != "" {
+		t.Fatalf("DF003 = %+v, want plain passing testcase", cases[2])
+	}
+	if cases[3].Skipped == nil || !strings.Contains(cases[3].SystemOut, "runtime image") {
+		t.Fatalf("DF005 = %+v, want skipped testcase", cases[3])
+	}
+	if cases[4].Failure != nil || !strings.HasPrefix(cases[4].SystemOut, "baselined:") {
+		t.Fatalf("DF006 = %+v, want baselined finding to pass", cases[4])
+	}
+
+	payload, err := MarshalJUnit(suites)
+	if err != nil {
+		t.Fatalf("MarshalJUnit() error = %v", err)
+	}
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<testsuites name="ContainSentry" tests="5" failures="1" skipped="1">`,
+		`<failure message="1 finding(s) of severity fail" type="fail">[fail] Dockerfile:1: FROM alpine:latest</failure>`,
+		`<skipped message="suppressed"></skipped>`,
+	} {
+		if !strings.Contains(string(payload), want) {
+			t.Fatalf("JUnit XML misses %q:\n%s", want, payload)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+)
+
+// position is a finding location flattened for line-oriented formats. Lines
+// and columns are 1-based; zero means unknown.
+type position struct {
+	File      string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	// Path and Origin are set for Compose fields.
+	Path   string
+	Origin string
+}
+
+func findingPosition(location any) position {
+	switch location := location.(type) {
+	case *dockerfile.SourceRef:
+		if location == nil {
+			return position{}
+		}
+		return findingPosition(*location)
+	case dockerfile.SourceRef:
+		pos := position{File: location.File, Line: location.Start.Line, EndLine: location.End.Line}
+		if pos.Line > 0 && location.End.Character > 0 {
+			pos.Column = location.Start.Character + 1
+			pos.EndColumn = location.End.Character + 1
+		}
+		return pos
+	case *model.Location:
+		if location == nil {
+			return position{}
+		}
+		return findingPosition(*location)
+	case model.Location:
+		pos := position{File: location.File, Line: location.Line, Column: location.Column, EndLine: location.Line, Path: location.Path, Origin: location.Origin}
+		if pos.File == "" && len(location.Files) > 0 {
+			pos.File = location.Files[0]
+		}
+		return pos
+	default:
+		return position{}
+	}
+}
@@ -0,0 +1,265 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+// markdownMarker lets CI bots find and update a previous comment in place.
+const markdownMarker = "<!-- containsentry-report -->"
+
+const defaultMarkdownFindings = 50
+
+type MarkdownOptions struct {
+	// LinkBase turns locations into links: LinkBase + path + "#L<line>", e.g.
+	// https://github.com/org/repo/blob/<sha>/.
+	LinkBase string
+	// BaseDir is prepended to reported paths in links, e.g. the scan root.
+	BaseDir string
+	// Baseline, when set, adds the baselined findings that are no longer
+	// reported to the counts.
+	Baseline *Baseline
+	// MaxFindings caps the findings table; defaults to 50.
+	MaxFindings int
+}
+
+// WriteMarkdown renders a compact pull request comment that renders the same
+// on GitHub and GitLab: a severity badge line, a table of new findings and a
+// collapsible section with mitigation per rule.
+func WriteMarkdown(w io.Writer, rep Report, opts MarkdownOptions) error {
+	var b strings.Builder
+	b.WriteString(markdownMarker + "\n")
+	b.WriteString("### " + toolName + "\n\n")
+	b.WriteString(markdownBadges(rep.Summary) + "\n\n")
+	b.WriteString(markdownCounts(rep, opts.Baseline) + "\n\n")
+
+	if len(rep.Findings) > 0 {
+		limit := opts.MaxFindings
+		if limit <= 0 {
+			limit = defaultMarkdownFindings
+		}
+		b.WriteString("| Severity | Rule | Location | Scope |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for i, finding := range rep.Findings {
+			if i == limit {
+				fmt.Fprintf(&b, "\n_%d more finding(s) not shown._\n", len(rep.Findings)-limit)
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownSeverity(finding.Severity),
+				markdownCell("**"+finding.ID+"** "+markdownText(finding.Name)),
+				markdownCell(markdownLocation(finding, opts)),
+				markdownCell(markdownScope(finding)),
+			)
+		}
+		b.WriteString("\n")
+
+		for _, group := range groupByRule(rep.Findings) {
+			b.WriteString(markdownRule(group, opts))
+		}
+	}
+
+	b.WriteString("---\n")
+	b.WriteString("<sub>" + markdownFooter(rep.Summary) + "</sub>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownBadges(summary ReportSummary) string {
+	parts := []string{
+		fmt.Sprintf("🔴 **%d fail**", summary.BySeverity["fail"]),
+		fmt.Sprintf("🟠 **%d warn**", summary.BySeverity["warn"]),
+		fmt.Sprintf("🔵 **%d info**", summary.BySeverity["info"]),
+	}
+	if gate := summary.Gate; gate != nil {
+		if gate.Passed {
+			parts = append(parts, fmt.Sprintf("✅ gate passed (fail_on=%s)", gate.FailOn))
+		} else {
+			parts = append(parts, fmt.Sprintf("❌ gate failed (fail_on=%s)", gate.FailOn))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func markdownCounts(rep Report, baseline *Baseline) string {
+	if rep.Summary.Total == 0 {
+		return "✅ No new findings." + markdownExtraCounts(rep, baseline)
+	}
+	return fmt.Sprintf("**%d new finding(s)**", rep.Summary.Total) + markdownExtraCounts(rep, baseline)
+}
+
+func markdownExtraCounts(rep Report, baseline *Baseline) string {
+	var parts []string
+	if rep.Summary.Baselined > 0 {
+		parts = append(parts, fmt.Sprintf("%d baselined", rep.Summary.Baselined))
+	}
+	if fixed := fixedSinceBaseline(rep, baseline); fixed > 0 {
+		parts = append(parts, fmt.Sprintf("%d fixed since baseline", fixed))
+	}
+	if rep.Summary.Suppressed > 0 {
+		parts = append(parts, fmt.Sprintf("%d suppressed", rep.Summary.Suppressed))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " · " + strings.Join(parts, " · ")
+}
+
+// fixedSinceBaseline counts baseline entries that no longer match a finding.
+func fixedSinceBaseline(rep Report, baseline *Baseline) int {
+	if baseline == nil {
+		return 0
+	}
+	current := map[string]struct{}{}
+	for _, list := range [][]ReportFinding{rep.Findings, rep.Baselined, rep.Suppressed} {
+		for _, finding := range list {
+			current[finding.Fingerprint] = struct{}{}
+		}
+	}
+	fixed := map[string]struct{}{}
+	for _, entry := range append(append([]BaselineEntry{}, baseline.Findings...), baseline.Baselined...) {
+		if _, ok := current[entry.Fingerprint]; !ok && entry.Fingerprint != "" {
+			fixed[entry.Fingerprint] = struct{}{}
+		}
+	}
+	return len(fixed)
+}
+
+func markdownSeverity(severity string) string {
+	label := strings.ToLower(strings.TrimSpace(severity))
+	switch entities.ParseSeverity(severity) {
+	case entities.SeverityFail:
+		return "🔴 " + label
+	case entities.SeverityWarn:
+		return "🟠 " + label
+	default:
+		return "🔵 " + label
+	}
+}
+
+func markdownScope(finding ReportFinding) string {
+	switch {
+	case finding.Service != "":
+		return "service `" + finding.Service + "`"
+	case finding.Stage != "":
+		return "stage `" + finding.Stage + "`"
+	default:
+		return finding.Target
+	}
+}
+
+// markdownLocation renders file:line, linked when a link base is known.
+func markdownLocation(finding ReportFinding, opts MarkdownOptions) string {
+	pos := findingPosition(finding.Location)
+	file := displayPath(pos.File)
+	if file == "" {
+		return markdownText(finding.Target)
+	}
+	label := file
+	if pos.Line > 0 {
+		label += ":" + strconv.Itoa(pos.Line)
+	}
+	if opts.LinkBase == "" || filepath.IsAbs(file) {
+		return "`" + label + "`"
+	}
+	target := path.Clean(path.Join(filepath.ToSlash(opts.BaseDir), file))
+	link := strings.TrimSuffix(opts.LinkBase, "/") + "/" + target
+	if pos.Line > 0 {
+		link += "#L" + strconv.Itoa(pos.Line)
+	}
+	return "[" + markdownText(label) + "](" + link + ")"
+}
+
+type ruleGroup struct {
+	first    ReportFinding
+	findings []ReportFinding
+}
+
+// groupByRule keeps the rules in order of their first finding.
+func groupByRule(findings []ReportFinding) []*ruleGroup {
+	var groups []*ruleGroup
+	index := map[string]*ruleGroup{}
+	for _, finding := range findings {
+		group, ok := index[finding.ID]
+		if !ok {
+			group = &ruleGroup{first: finding}
+			index[finding.ID] = group
+			groups = append(groups, group)
+		}
+		group.findings = append(group.findings, finding)
+	}
+	return groups
+}
+
+func markdownRule(group *ruleGroup, opts MarkdownOptions) string {
+	var b strings.Builder
+	rule := group.first
+	fmt.Fprintf(&b, "<details>\n<summary>%s <b>%s</b> %s (%d)</summary>\n\n",
+		markdownSeverity(rule.Severity), rule.ID, markdownText(rule.Name), len(group.findings))
+	if rule.Description != "" {
+		b.WriteString(markdownText(rule.Description) + "\n\n")
+	}
+	if rule.Mitigation != "" {
+		b.WriteString("**Mitigation:** " + markdownText(rule.Mitigation) + "\n\n")
+	}
+	if rule.Reference != "" {
+		b.WriteString("**Reference:** " + markdownText(rule.Reference) + "\n\n")
+	}
+	for _, finding := range group.findings {
+		line := "- " + markdownLocation(finding, opts)
+		if sample := markdownSample(finding); sample != "" {
+			line += " " + sample
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n</details>\n\n")
+	return b.String()
+}
+
+// markdownSample is the first line of the code sample as inline code.
+func markdownSample(finding ReportFinding) string {
+	sample := strings.TrimSpace(finding.CodeSample)
+	if pos := findingPosition(finding.Location); sample == pos.Path {
+		return ""
+	}
+	if i := strings.IndexByte(sample, '\n'); i >= 0 {
+		sample = strings.TrimSpace(sample[:i]) + " …"
+	}
+	if sample == "" {
+		return ""
+	}
+	if strings.Contains(sample, "`") {
+		return "`` " + sample + " ``"
+	}
+	return "`" + sample + "`"
+}
+
+func markdownFooter(summary ReportSummary) string {
+	footer := toolName
+	if ruleSet := summary.RuleSet; ruleSet != nil {
+		footer += " · rules " + ruleSet.Version
+		if ruleSet.Rules > 0 {
+			footer += fmt.Sprintf(" (%d rules)", ruleSet.Rules)
+		}
+		for _, pack := range ruleSet.Packs {
+			footer += " · " + markdownText(pack.String())
+		}
+	}
+	return footer
+}
+
+// markdownText keeps rule text from being read as HTML by either renderer.
+func markdownText(text string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// markdownCell escapes table separators and folds line breaks.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
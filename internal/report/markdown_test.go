@@ -0,0 +1,69 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	fixed := entities.Finding{ID: "DF013", Severity: "warn", Target: "dockerfile", Stage: "build", CodeSample: "RUN curl https://x"}
+	known := entities.Finding{ID: "DF002", Severity: "warn", Target: "dockerfile", Stage: "build", CodeSample: "FROM alpine:latest"}
+	baseline := NewBaseline([]entities.Finding{fixed, known})
+	location := dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 1, Character: 11}, End: dockerfile.Position{Line: 1, Character: 18}}
+
+	rep := Build([]entities.Finding{
+		{
+			ID: "DF001", Name: "Latest tag", Severity: "fail", Target: "dockerfile", Stage: "build", CodeSample: "FROM alpine:latest",
+			Description: "Mutable <latest> tags.", Mitigation: "Pin the version.", Location: location,
+		},
+		{
+			ID: "DF001", Name: "Latest tag", Severity: "fail", Target: "dockerfile", Stage: "app", CodeSample: "FROM node:latest",
+			Location: dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 5}, End: dockerfile.Position{Line: 5}},
+		},
+		{
+			ID: "CP013", Name: "Missing | healthcheck", Severity: "warn", Target: "compose", Service: "api", CodeSample: "services.api",
+			Location: model.Location{Path: "services.api", File: "compose.yaml", Line: 2, Column: 3},
+		},
+		known,
+		{ID: "DF022", Severity: "warn", Target: "dockerfile", Suppression: &entities.Suppression{Reason: "orchestrator"}},
+	},
+		WithBaseline(&baseline),
+		WithFailOn(entities.SeverityFail),
+		WithRuleSet(RuleSetSummary{Version: "sha256:0123456789ab", Rules: 47, Packs: []RulePack{{Name: "baseline", Version: "1.2.0", Rules: 47}}}),
+	)
+
+	var out bytes.Buffer
+	err := WriteMarkdown(&out, rep, MarkdownOptions{LinkBase: "https://github.com/org/repo/blob/abc/", BaseDir: "services", Baseline: &baseline})
+	if err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"<!-- containsentry-report -->\n### ContainSentry\n\n",
+		"🔴 **2 fail** · 🟠 **1 warn** · 🔵 **0 info** · ❌ gate failed (fail_on=fail)\n",
+		"**3 new finding(s)** · 1 baselined · 1 fixed since baseline · 1 suppressed\n",
+		"| 🔴 fail | **DF001** Latest tag | [Dockerfile:1](https://github.com/org/repo/blob/abc/services/Dockerfile#L1) | stage `build` |\n",
+		"| 🟠 warn | **CP013** Missing \\| healthcheck | [compose.yaml:2](https://github.com/org/repo/blob/abc/services/compose.yaml#L2) | service `api` |\n",
+		"<details>\n<summary>🔴 fail <b>DF001</b> Latest tag (2)</summary>\n\nMutable &lt;latest&gt; tags.\n\n**Mitigation:** Pin the version.\n\n",
+		"- [Dockerfile:5](https://github.com/org/repo/blob/abc/services/Dockerfile#L5) `FROM node:latest`\n",
+		"- [compose.yaml:2](https://github.com/org/repo/blob/abc/services/compose.yaml#L2)\n\n</details>",
+		"---\n<sub>ContainSentry · rules sha256:0123456789ab (47 rules) · baseline 1.2.0</sub>\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("markdown misses %q:\n%s", want, text)
+		}
+	}
+
+	out.Reset()
+	if err := WriteMarkdown(&out, Build(nil), MarkdownOptions{MaxFindings: 1}); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "✅ No new findings.\n") || strings.Contains(out.String(), "| Severity |") {
+		t.Fatalf("empty report:\n%s", out.String())
+	}
+}
@@ -3,64 +3,288 @@ package report
 import (
 	"encoding/json"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/katvixlab/contain-sentry/internal/entities"
 )
 
 type Report struct {
-	Findings []ReportFinding `json:"findings"`
-	Summary  ReportSummary   `json:"summary"`
+	Findings   []ReportFinding `json:"findings"`
+	Suppressed []ReportFinding `json:"suppressed,omitempty"`
+	Baselined  []ReportFinding `json:"baselined,omitempty"`
+	Errors     []ArtifactError `json:"errors,omitempty"`
+	Summary    ReportSummary   `json:"summary"`
+}
+
+// ArtifactError records an artifact that could not be loaded and therefore
+// was not analyzed.
+type ArtifactError struct {
+	Target string   `json:"target"`
+	Files  []string `json:"files,omitempty"`
+	Error  string   `json:"error"`
 }
 
 type ReportFinding struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Severity    string `json:"severity,omitempty"`
-	Description string `json:"description,omitempty"`
-	Mitigation  string `json:"mitigation,omitempty"`
-	Reference   string `json:"reference,omitempty"`
-	CodeSample  string `json:"code_sample,omitempty"`
-	Location    any    `json:"location,omitempty"`
-	Target      string `json:"target,omitempty"`
-	Subject     string `json:"subject,omitempty"`
+	ID               string `json:"id,omitempty"`
+	Fingerprint      string `json:"fingerprint,omitempty"`
+	Name             string `json:"name,omitempty"`
+	Severity         string `json:"severity,omitempty"`
+	OriginalSeverity string `json:"original_severity,omitempty"`
+	Description      string `json:"description,omitempty"`
+	Mitigation       string `json:"mitigation,omitempty"`
+	Reference        string `json:"reference,omitempty"`
+	CodeSample       string `json:"code_sample,omitempty"`
+	Location         any    `json:"location,omitempty"`
+	Target           string `json:"target,omitempty"`
+	Subject          string `json:"subject,omitempty"`
+	Service          string `json:"service,omitempty"`
+	Stage            string `json:"stage,omitempty"`
+
+	Suppression *entities.Suppression `json:"suppression,omitempty"`
 }
 
 type ReportSummary struct {
+	Total      int             `json:"total"`
+	Suppressed int             `json:"suppressed,omitempty"`
+	Baselined  int             `json:"baselined,omitempty"`
+	BySeverity map[string]int  `json:"by_severity,omitempty"`
+	ByTarget   []TargetSummary `json:"by_target,omitempty"`
+	Gate       *Gate           `json:"gate,omitempty"`
+	RuleSet    *RuleSetSummary `json:"rule_set,omitempty"`
+}
+
+// RuleSetSummary identifies the rules a report was produced with.
+type RuleSetSummary struct {
+	Version string     `json:"version"`
+	Rules   int        `json:"rules"`
+	Sources []string   `json:"sources,omitempty"`
+	Packs   []RulePack `json:"packs,omitempty"`
+}
+
+// RulePack is a named rule pack that contributed rules.
+type RulePack struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Rules   int    `json:"rules"`
+}
+
+func (p RulePack) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + " " + p.Version
+}
+
+// TargetSummary holds the counters of one analysis target, e.g. the
+// Dockerfile or the Compose project, within a combined report.
+type TargetSummary struct {
+	Target     string         `json:"target"`
+	Artifacts  []string       `json:"artifacts,omitempty"`
 	Total      int            `json:"total"`
+	Suppressed int            `json:"suppressed,omitempty"`
+	Baselined  int            `json:"baselined,omitempty"`
 	BySeverity map[string]int `json:"by_severity,omitempty"`
 }
 
-func Build(findings []entities.Finding) Report {
+type Option func(*buildOptions)
+
+type buildOptions struct {
+	failOn   *entities.Severity
+	baseline *Baseline
+	targets  []TargetSummary
+	ruleSet  *RuleSetSummary
+	errors   []ArtifactError
+}
+
+// WithFailOn evaluates the severity gate for the report summary.
+func WithFailOn(threshold entities.Severity) Option {
+	return func(x *buildOptions) {
+		x.failOn = &threshold
+	}
+}
+
+// WithBaseline moves findings already recorded in the baseline out of the
+// main findings list, so that only new findings are counted and gated.
+func WithBaseline(baseline *Baseline) Option {
+	return func(x *buildOptions) {
+		x.baseline = baseline
+	}
+}
+
+// WithTarget registers an analyzed target and its artifacts. Registered
+// targets are listed in the summary even without findings, and findings are
+// grouped in registration order.
+func WithTarget(target string, artifacts ...string) Option {
+	return func(x *buildOptions) {
+		x.targets = append(x.targets, TargetSummary{Target: target, Artifacts: artifacts})
+	}
+}
+
+// WithRuleSet records the rule set version in the summary.
+func WithRuleSet(ruleSet RuleSetSummary) Option {
+	return func(x *buildOptions) {
+		x.ruleSet = &ruleSet
+	}
+}
+
+// WithErrors records artifacts that failed to load. Any such error fails
+// the severity gate, because the artifact was not analyzed.
+func WithErrors(errs ...ArtifactError) Option {
+	return func(x *buildOptions) {
+		x.errors = append(x.errors, errs...)
+	}
+}
+
+func Build(findings []entities.Finding, opts ...Option) Report {
+	options := buildOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	reportFindings := make([]ReportFinding, 0, len(findings))
+	var suppressed []ReportFinding
+	var baselined []ReportFinding
 	bySeverity := map[string]int{}
-	for _, finding := range findings {
-		reportFindings = append(reportFindings, ReportFinding{
-			ID:          finding.ID,
-			Name:        finding.Name,
-			Severity:    finding.Severity,
-			Description: finding.Description,
-			Mitigation:  finding.Mitigation,
-			Reference:   finding.Reference,
-			CodeSample:  finding.CodeSample,
-			Location:    finding.Location,
-			Target:      finding.Target,
-			Subject:     finding.Subject,
-		})
+	targets := newTargetIndex(options.targets)
+	for _, finding := range groupByTarget(findings, options.targets) {
+		fingerprint := Fingerprint(finding)
+		target := targets.get(finding.Target)
+		reportFinding := ReportFinding{
+			ID:               finding.ID,
+			Fingerprint:      fingerprint,
+			Name:             finding.Name,
+			Severity:         finding.Severity,
+			OriginalSeverity: finding.OriginalSeverity,
+			Description:      finding.Description,
+			Mitigation:       finding.Mitigation,
+			Reference:        finding.Reference,
+			CodeSample:       finding.CodeSample,
+			Location:         finding.Location,
+			Target:           finding.Target,
+			Subject:          finding.Subject,
+			Service:          finding.Service,
+			Stage:            finding.Stage,
+			Suppression:      finding.Suppression,
+		}
+		// Suppressed findings stay visible for audit but do not count
+		// toward totals or the severity gate.
+		if finding.Suppression != nil {
+			suppressed = append(suppressed, reportFinding)
+			target.Suppressed++
+			continue
+		}
+		if options.baseline != nil && options.baseline.Contains(fingerprint) {
+			baselined = append(baselined, reportFinding)
+			target.Baselined++
+			continue
+		}
+		reportFindings = append(reportFindings, reportFinding)
 		severity := strings.ToLower(strings.TrimSpace(finding.Severity))
 		if severity == "" {
 			severity = "unknown"
 		}
 		bySeverity[severity]++
+		target.Total++
+		target.BySeverity[severity]++
 	}
 
-	return Report{
-		Findings: reportFindings,
+	report := Report{
+		Findings:   reportFindings,
+		Suppressed: suppressed,
+		Baselined:  baselined,
+		Errors:     options.errors,
 		Summary: ReportSummary{
 			Total:      len(reportFindings),
+			Suppressed: len(suppressed),
+			Baselined:  len(baselined),
 			BySeverity: bySeverity,
+			ByTarget:   targets.summaries(),
+			RuleSet:    options.ruleSet,
 		},
 	}
+	if options.failOn != nil {
+		gate := EvaluateGate(reportFindings, *options.failOn)
+		if len(options.errors) > 0 {
+			gate.Errors = len(options.errors)
+			if gate.Passed {
+				gate.Passed = false
+				gate.ExitCode = ExitCodeError
+			}
+		}
+		report.Summary.Gate = &gate
+	}
+	return report
+}
+
+// groupByTarget orders findings by registered target, keeping the engine
+// order within a target. Unregistered targets follow in order of appearance.
+func groupByTarget(findings []entities.Finding, targets []TargetSummary) []entities.Finding {
+	order := map[string]int{}
+	for _, target := range targets {
+		key := strings.ToLower(target.Target)
+		if _, ok := order[key]; !ok {
+			order[key] = len(order)
+		}
+	}
+	for _, finding := range findings {
+		key := strings.ToLower(finding.Target)
+		if _, ok := order[key]; !ok {
+			order[key] = len(order)
+		}
+	}
+
+	grouped := append([]entities.Finding{}, findings...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return order[strings.ToLower(grouped[i].Target)] < order[strings.ToLower(grouped[j].Target)]
+	})
+	return grouped
+}
+
+type targetIndex struct {
+	items []*TargetSummary
+	byKey map[string]*TargetSummary
+}
+
+func newTargetIndex(targets []TargetSummary) *targetIndex {
+	index := &targetIndex{byKey: map[string]*TargetSummary{}}
+	for _, target := range targets {
+		summary := index.get(target.Target)
+		for _, artifact := range target.Artifacts {
+			if !slices.Contains(summary.Artifacts, artifact) {
+				summary.Artifacts = append(summary.Artifacts, artifact)
+			}
+		}
+	}
+	return index
+}
+
+func (x *targetIndex) get(target string) *TargetSummary {
+	key := strings.ToLower(strings.TrimSpace(target))
+	if summary, ok := x.byKey[key]; ok {
+		return summary
+	}
+	summary := &TargetSummary{Target: key, BySeverity: map[string]int{}}
+	x.byKey[key] = summary
+	x.items = append(x.items, summary)
+	return summary
+}
+
+func (x *targetIndex) summaries() []TargetSummary {
+	if len(x.items) == 0 {
+		return nil
+	}
+	summaries := make([]TargetSummary, 0, len(x.items))
+	for _, item := range x.items {
+		summary := *item
+		if len(summary.BySeverity) == 0 {
+			summary.BySeverity = nil
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
 }
 
 func MarshalJSON(report Report) ([]byte, error) {
@@ -55,3 +55,116 @@ func TestMarshalAndWriteJSON(t *testing.T) {
 		t.Fatalf("report file not written: %v", err)
 	}
 }
+
+func TestBuildEvaluatesGate(t *testing.T) {
+	findings := []entities.Finding{
+		{ID: "1", Severity: "warn"},
+		{ID: "2", Severity: "fail"},
+	}
+
+	tests := []struct {
+		name      string
+		threshold entities.Severity
+		passed    bool
+		exitCode  int
+	}{
+		{name: "disabled gate", threshold: entities.SeverityNone, passed: true, exitCode: ExitCodeOK},
+		{name: "fail threshold", threshold: entities.SeverityFail, passed: false, exitCode: ExitCodeGateFail},
+		{name: "any threshold", threshold: entities.SeverityInfo, passed: false, exitCode: ExitCodeGateFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := Build(findings, WithFailOn(tt.threshold)).Summary.Gate
+			if gate == nil {
+				t.Fatalf("Gate is nil")
+			}
+			if gate.Passed != tt.passed || gate.ExitCode != tt.exitCode || gate.Highest != "fail" {
+				t.Fatalf("unexpected gate: %+v", gate)
+			}
+		})
+	}
+
+	gate := Build([]entities.Finding{{ID: "1", Severity: "warn"}}, WithFailOn(entities.SeverityWarn)).Summary.Gate
+	if gate.Passed || gate.ExitCode != ExitCodeGateWarn {
+		t.Fatalf("unexpected warn gate: %+v", gate)
+	}
+	if Build(findings).Summary.Gate != nil {
+		t.Fatalf("Gate must be omitted without WithFailOn")
+	}
+}
+
+func TestBuildSeparatesSuppressedFindings(t *testing.T) {
+	report := Build([]entities.Finding{
+		{ID: "DF001", Severity: "fail"},
+		{ID: "DF002", Severity: "fail", Suppression: &entities.Suppression{Rules: []string{"DF002"}, Reason: "vendor image"}},
+	}, WithFailOn(entities.SeverityFail))
+
+	if report.Summary.Total != 1 || report.Summary.Suppressed != 1 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+	if len(report.Suppressed) != 1 || report.Suppressed[0].Suppression.Reason != "vendor image" {
+		t.Fatalf("unexpected suppressed findings: %+v", report.Suppressed)
+	}
+	if report.Summary.BySeverity["fail"] != 1 {
+		t.Fatalf("suppressed findings must not be counted by severity: %+v", report.Summary.BySeverity)
+	}
+}
+
+func TestBuildGroupsFindingsByTarget(t *testing.T) {
+	report := Build([]entities.Finding{
+		{ID: "DF001", Target: "dockerfile", Severity: "fail"},
+		{ID: "CP001", Target: "compose", Severity: "warn"},
+		{ID: "DF002", Target: "dockerfile", Severity: "warn", Suppression: &entities.Suppression{Rules: []string{"DF002"}}},
+		{ID: "CP002", Target: "compose", Severity: "fail"},
+	}, WithTarget("compose", "compose.yaml"), WithTarget("dockerfile", "Dockerfile"))
+
+	var ids []string
+	for _, finding := range report.Findings {
+		ids = append(ids, finding.ID)
+	}
+	if len(ids) != 3 || ids[0] != "CP001" || ids[1] != "CP002" || ids[2] != "DF001" {
+		t.Fatalf("findings = %v, want compose findings first", ids)
+	}
+
+	byTarget := report.Summary.ByTarget
+	if len(byTarget) != 2 || byTarget[0].Target != "compose" || byTarget[1].Target != "dockerfile" {
+		t.Fatalf("ByTarget = %+v", byTarget)
+	}
+	if byTarget[0].Total != 2 || byTarget[0].BySeverity["fail"] != 1 || byTarget[0].Artifacts[0] != "compose.yaml" {
+		t.Fatalf("compose summary = %+v", byTarget[0])
+	}
+	if byTarget[1].Total != 1 || byTarget[1].Suppressed != 1 {
+		t.Fatalf("dockerfile summary = %+v", byTarget[1])
+	}
+}
+
+func TestBuildListsRegisteredTargetsWithoutFindings(t *testing.T) {
+	report := Build(nil, WithTarget("dockerfile", "Dockerfile"))
+	if len(report.Summary.ByTarget) != 1 || report.Summary.ByTarget[0].Total != 0 {
+		t.Fatalf("ByTarget = %+v", report.Summary.ByTarget)
+	}
+
+	report = Build(nil, WithTarget("compose", "compose.yaml"), WithTarget("compose", "compose.yaml", "docker-compose.prod.yml"))
+	if artifacts := report.Summary.ByTarget[0].Artifacts; len(artifacts) != 2 || artifacts[0] != "compose.yaml" || artifacts[1] != "docker-compose.prod.yml" {
+		t.Fatalf("Artifacts = %v, want each file once", artifacts)
+	}
+}
+
+func TestBuildFailsGateOnArtifactErrors(t *testing.T) {
+	failure := ArtifactError{Target: "compose", Files: []string{"compose.yaml"}, Error: "yaml: line 1: did not find expected node content"}
+
+	rep := Build(nil, WithFailOn(entities.SeverityNone), WithErrors(failure))
+	gate := rep.Summary.Gate
+	if gate.Passed || gate.ExitCode != ExitCodeError || gate.Errors != 1 {
+		t.Fatalf("unexpected gate: %+v", gate)
+	}
+	if len(rep.Errors) != 1 || rep.Errors[0].Target != "compose" {
+		t.Fatalf("unexpected errors: %+v", rep.Errors)
+	}
+
+	gate = Build([]entities.Finding{{ID: "1", Severity: "fail"}}, WithFailOn(entities.SeverityInfo), WithErrors(failure)).Summary.Gate
+	if gate.Passed || gate.ExitCode != ExitCodeGateFail || gate.Errors != 1 {
+		t.Fatalf("findings must keep the gate exit code: %+v", gate)
+	}
+}
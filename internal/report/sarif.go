@@ -0,0 +1,340 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "ContainSentry"
+	toolInfoURI  = "https://github.com/katvixlab/contain-sentry"
+)
+
+type SARIFOptions struct {
+	// BaseDir is used to make artifact URIs relative; defaults to the working directory.
+	BaseDir string
+	// Artifacts maps a target to the artifact path used when a finding location
+	// does not name a file itself.
+	Artifacts map[string]string
+	// ToolVersion is reported as tool.driver.version.
+	ToolVersion string
+}
+
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     *SARIFMessage      `json:"shortDescription,omitempty"`
+	FullDescription      *SARIFMessage      `json:"fullDescription,omitempty"`
+	Help                 *SARIFMessage      `json:"help,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any     `json:"properties,omitempty"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      SARIFMessage       `json:"message"`
+	Locations    []SARIFLocation    `json:"locations,omitempty"`
+	Suppressions []SARIFSuppression `json:"suppressions,omitempty"`
+
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status,omitempty"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine   int           `json:"startLine,omitempty"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *SARIFMessage `json:"snippet,omitempty"`
+}
+
+type SARIFLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+func BuildSARIF(findings []entities.Finding, opts SARIFOptions) SARIFLog {
+	rules := make([]SARIFRule, 0)
+	ruleIndex := map[string]int{}
+	results := make([]SARIFResult, 0, len(findings))
+
+	for _, finding := range findings {
+		index, ok := ruleIndex[finding.ID]
+		if !ok {
+			index = len(rules)
+			ruleIndex[finding.ID] = index
+			rules = append(rules, sarifRule(finding))
+		}
+
+		message := finding.Name
+		if message == "" {
+			message = finding.ID
+		}
+		if origin := findingOrigin(finding); origin != "" {
+			message += " (" + origin + ")"
+		}
+		result := SARIFResult{
+			RuleID:    finding.ID,
+			RuleIndex: index,
+			Level:     SARIFLevel(finding.Severity),
+			Message:   SARIFMessage{Text: message},
+
+			PartialFingerprints: map[string]string{"containsentry/v1": Fingerprint(finding)},
+		}
+		if location, ok := sarifLocation(finding, opts); ok {
+			result.Locations = []SARIFLocation{location}
+		}
+		if finding.Suppression != nil {
+			result.Suppressions = []SARIFSuppression{{
+				Kind:          "inSource",
+				Status:        "accepted",
+				Justification: finding.Suppression.Reason,
+			}}
+		}
+		results = append(results, result)
+	}
+
+	return SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           toolName,
+				Version:        opts.ToolVersion,
+				InformationURI: toolInfoURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// SARIFLevel maps ContainSentry severities onto SARIF result levels.
+func SARIFLevel(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "fail", "error":
+		return "error"
+	case "warn", "warning":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func MarshalSARIF(log SARIFLog) ([]byte, error) {
+	return json.MarshalIndent(log, "", "  ")
+}
+
+func WriteSARIF(path string, log SARIFLog) error {
+	payload, err := MarshalSARIF(log)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
+
+func sarifRule(finding entities.Finding) SARIFRule {
+	rule := SARIFRule{
+		ID:                   finding.ID,
+		Name:                 finding.Name,
+		DefaultConfiguration: SARIFConfiguration{Level: SARIFLevel(finding.Severity)},
+		Properties:           map[string]any{},
+	}
+	if finding.Name != "" {
+		rule.ShortDescription = &SARIFMessage{Text: finding.Name}
+	}
+	if finding.Description != "" {
+		rule.FullDescription = &SARIFMessage{Text: finding.Description}
+	}
+
+	help := make([]string, 0, 2)
+	if finding.Mitigation != "" {
+		help = append(help, finding.Mitigation)
+	}
+	if finding.Reference != "" {
+		help = append(help, "Reference: "+finding.Reference)
+		rule.Properties["reference"] = finding.Reference
+	}
+	if len(help) > 0 {
+		rule.Help = &SARIFMessage{Text: strings.Join(help, "\n\n")}
+	}
+	if finding.Target != "" {
+		rule.Properties["target"] = finding.Target
+	}
+	if finding.Severity != "" {
+		rule.Properties["severity"] = finding.Severity
+	}
+	if len(rule.Properties) == 0 {
+		rule.Properties = nil
+	}
+	return rule
+}
+
+func sarifLocation(finding entities.Finding, opts SARIFOptions) (SARIFLocation, bool) {
+	switch location := finding.Location.(type) {
+	case dockerfile.SourceRef:
+		return dockerfileSARIFLocation(location, finding, opts)
+	case *dockerfile.SourceRef:
+		if location == nil {
+			return SARIFLocation{}, false
+		}
+		return dockerfileSARIFLocation(*location, finding, opts)
+	case model.Location:
+		return composeSARIFLocation(location, finding, opts)
+	case *model.Location:
+		if location == nil {
+			return SARIFLocation{}, false
+		}
+		return composeSARIFLocation(*location, finding, opts)
+	default:
+		artifact := opts.Artifacts[finding.Target]
+		if artifact == "" {
+			return SARIFLocation{}, false
+		}
+		return SARIFLocation{PhysicalLocation: &SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(artifact, opts.BaseDir)},
+		}}, true
+	}
+}
+
+func dockerfileSARIFLocation(ref dockerfile.SourceRef, finding entities.Finding, opts SARIFOptions) (SARIFLocation, bool) {
+	artifact := ref.File
+	if artifact == "" {
+		artifact = opts.Artifacts[finding.Target]
+	}
+	if artifact == "" {
+		return SARIFLocation{}, false
+	}
+
+	region := &SARIFRegion{StartLine: ref.Start.Line, EndLine: ref.End.Line}
+	if region.StartLine <= 0 {
+		// The eof step has no position; SARIF consumers still need a region.
+		region.StartLine = 1
+		region.EndLine = 0
+	} else if ref.End.Character > 0 {
+		// SARIF columns are 1-based; SourceRef characters are 0-based.
+		region.StartColumn = ref.Start.Character + 1
+		region.EndColumn = ref.End.Character + 1
+	}
+	if finding.CodeSample != "" {
+		region.Snippet = &SARIFMessage{Text: finding.CodeSample}
+	}
+
+	return SARIFLocation{PhysicalLocation: &SARIFPhysicalLocation{
+		ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(artifact, opts.BaseDir)},
+		Region:           region,
+	}}, true
+}
+
+func composeSARIFLocation(loc model.Location, finding entities.Finding, opts SARIFOptions) (SARIFLocation, bool) {
+	artifact := opts.Artifacts[finding.Target]
+	if loc.File != "" {
+		artifact = loc.File
+	} else if len(loc.Files) > 0 {
+		artifact = loc.Files[0]
+	}
+
+	location := SARIFLocation{}
+	if artifact != "" {
+		region := &SARIFRegion{StartLine: loc.Line, StartColumn: loc.Column}
+		if region.StartLine <= 0 {
+			// Without a position the finding is anchored to the file start.
+			region = &SARIFRegion{StartLine: 1}
+		}
+		location.PhysicalLocation = &SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(artifact, opts.BaseDir)},
+			Region:           region,
+		}
+	}
+	if loc.Path != "" {
+		location.LogicalLocations = []SARIFLogicalLocation{{FullyQualifiedName: loc.Path, Kind: "member"}}
+	}
+	return location, location.PhysicalLocation != nil || len(location.LogicalLocations) > 0
+}
+
+func findingOrigin(finding entities.Finding) string {
+	switch location := finding.Location.(type) {
+	case model.Location:
+		return location.Origin
+	case *model.Location:
+		if location != nil {
+			return location.Origin
+		}
+	}
+	return ""
+}
+
+func sarifURI(path string, baseDir string) string {
+	if baseDir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			baseDir = wd
+		}
+	}
+	if filepath.IsAbs(path) && baseDir != "" {
+		if abs, err := filepath.Abs(baseDir); err == nil {
+			if rel, err := filepath.Rel(abs, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestBuildSARIF(t *testing.T) {
+	log := BuildSARIF([]entities.Finding{
+		{
+			ID:          "DF001",
+			Name:        "Base image uses latest tag",
+			Severity:    "fail",
+			Description: "desc",
+			Mitigation:  "pin it",
+			Reference:   "docs",
+			CodeSample:  "FROM alpine:latest",
+			Target:      "dockerfile",
+			Location: dockerfile.SourceRef{
+				Start: dockerfile.Position{Line: 1, Character: 11},
+				End:   dockerfile.Position{Line: 1, Character: 18},
+			},
+		},
+		{
+			ID:       "DF001",
+			Name:     "Base image uses latest tag",
+			Severity: "fail",
+			Target:   "dockerfile",
+			Location: dockerfile.SourceRef{
+				Start: dockerfile.Position{Line: 4},
+				End:   dockerfile.Position{Line: 5},
+			},
+		},
+		{
+			ID:       "CP024",
+			Name:     "Public port",
+			Severity: "warn",
+			Target:   "compose",
+			Location: model.Location{Files: []string{"/repo/compose.yaml"}, ServiceName: "app", Path: "services.app.ports", File: "/repo/compose.prod.yaml", Line: 7, Column: 5},
+		},
+	}, SARIFOptions{
+		BaseDir:     "/repo",
+		Artifacts:   map[string]string{"dockerfile": "Dockerfile"},
+		ToolVersion: "v1.2.3",
+	})
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log envelope: %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "v1.2.3" {
+		t.Fatalf("driver version = %q, want v1.2.3", run.Tool.Driver.Version)
+	}
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("rules = %d, want 2", len(run.Tool.Driver.Rules))
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ID != "DF001" || rule.FullDescription == nil || rule.FullDescription.Text != "desc" || rule.Help == nil {
+		t.Fatalf("unexpected rule metadata: %+v", rule)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("results = %d, want 3", len(run.Results))
+	}
+
+	first := run.Results[0].Locations[0].PhysicalLocation.Region
+	if first.StartLine != 1 || first.StartColumn != 12 || first.EndColumn != 19 {
+		t.Fatalf("unexpected highlighted region: %+v", first)
+	}
+
+	second := run.Results[1]
+	if second.RuleIndex != 0 || second.Level != "error" {
+		t.Fatalf("unexpected dockerfile result: %+v", second)
+	}
+	region := second.Locations[0].PhysicalLocation.Region
+	if second.Locations[0].PhysicalLocation.ArtifactLocation.URI != "Dockerfile" || region.StartLine != 4 || region.EndLine != 5 || region.StartColumn != 0 {
+		t.Fatalf("unexpected dockerfile location: %+v", second.Locations[0].PhysicalLocation)
+	}
+
+	third := run.Results[2]
+	if third.RuleIndex != 1 || third.Level != "warning" {
+		t.Fatalf("unexpected compose result: %+v", third)
+	}
+	if third.Locations[0].PhysicalLocation.ArtifactLocation.URI != "compose.prod.yaml" {
+		t.Fatalf("compose uri = %q, want compose.prod.yaml", third.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	if region := third.Locations[0].PhysicalLocation.Region; region.StartLine != 7 || region.StartColumn != 5 {
+		t.Fatalf("unexpected compose region: %+v", region)
+	}
+	if third.Locations[0].LogicalLocations[0].FullyQualifiedName != "services.app.ports" {
+		t.Fatalf("unexpected logical location: %+v", third.Locations[0].LogicalLocations)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.sarif")
+	if err := WriteSARIF(path, BuildSARIF(nil, SARIFOptions{})); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	runs, ok := decoded["runs"].([]any)
+	if !ok || len(runs) != 1 {
+		t.Fatalf("unexpected runs: %v", decoded["runs"])
+	}
+	results, ok := runs[0].(map[string]any)["results"].([]any)
+	if !ok || len(results) != 0 {
+		t.Fatalf("results must be an empty array, got %v", runs[0].(map[string]any)["results"])
+	}
+}
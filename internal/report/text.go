@@ -0,0 +1,301 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+type TextOptions struct {
+	// Color enables ANSI colors, typically when the output is a terminal.
+	Color bool
+}
+
+// WriteText renders the report for a terminal: findings grouped by file, then
+// stage or service, then severity, followed by suppressed findings and a
+// summary table.
+func WriteText(w io.Writer, rep Report, opts TextOptions) error {
+	p := &textPrinter{w: w, color: opts.Color}
+
+	if len(rep.Findings) == 0 {
+		p.line(p.paint(ansiBold, "No findings."))
+	}
+	for _, file := range groupFindings(rep) {
+		p.line(p.paint(ansiBold, file.name))
+		for _, scope := range file.scopes {
+			if scope.name != "" {
+				p.line("  " + p.paint(ansiDim, scope.name))
+			}
+			for _, finding := range scope.findings {
+				p.finding(finding)
+			}
+		}
+		p.line("")
+	}
+
+	if len(rep.Suppressed) > 0 {
+		p.line(p.paint(ansiBold, fmt.Sprintf("Suppressed (%d)", len(rep.Suppressed))))
+		for _, finding := range rep.Suppressed {
+			reason := ""
+			if finding.Suppression != nil && finding.Suppression.Reason != "" {
+				reason = " - " + finding.Suppression.Reason
+			}
+			p.line(fmt.Sprintf("  %s %s%s %s", finding.ID, finding.Name, reason, p.paint(ansiDim, positionLabel(findingPosition(finding.Location), finding.Target))))
+		}
+		p.line("")
+	}
+
+	if len(rep.Errors) > 0 {
+		p.line(p.paint(ansiBold+ansiRed, fmt.Sprintf("Errors (%d)", len(rep.Errors))))
+		for _, failure := range rep.Errors {
+			files := make([]string, 0, len(failure.Files))
+			for _, file := range failure.Files {
+				files = append(files, displayPath(file))
+			}
+			p.line(fmt.Sprintf("  %s %s", failure.Target, p.paint(ansiDim, strings.Join(files, ", "))))
+			p.line("    " + failure.Error)
+		}
+		p.line("")
+	}
+
+	p.summary(rep.Summary)
+	return p.err
+}
+
+type textPrinter struct {
+	w     io.Writer
+	color bool
+	err   error
+}
+
+func (p *textPrinter) line(text string) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintln(p.w, text)
+}
+
+func (p *textPrinter) paint(code string, text string) string {
+	if !p.color || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+func (p *textPrinter) severity(severity string) string {
+	label := strings.ToUpper(strings.TrimSpace(severity))
+	if label == "" {
+		label = "UNKNOWN"
+	}
+	switch entities.ParseSeverity(severity) {
+	case entities.SeverityFail:
+		return p.paint(ansiBold+ansiRed, label)
+	case entities.SeverityWarn:
+		return p.paint(ansiYellow, label)
+	default:
+		return p.paint(ansiCyan, label)
+	}
+}
+
+func (p *textPrinter) finding(finding ReportFinding) {
+	pos := findingPosition(finding.Location)
+	p.line(fmt.Sprintf("    %s %s %s", p.severity(finding.Severity), p.paint(ansiBold, finding.ID), finding.Name))
+	if label := positionLabel(pos, ""); label != "" {
+		if pos.Origin != "" {
+			label += " (" + pos.Origin + ")"
+		}
+		p.line("      " + p.paint(ansiDim, label))
+	}
+
+	sample := strings.TrimRight(finding.CodeSample, "\n")
+	if pos.Path != "" {
+		// Compose samples are field values; service-level findings only
+		// repeat the path.
+		if sample == pos.Path {
+			sample = ""
+		} else if sample != "" {
+			sample = pos.Path[strings.LastIndex(pos.Path, ".")+1:] + ": " + sample
+		}
+	}
+	if sample != "" {
+		lines := strings.Split(sample, "\n")
+		first := pos.Line
+		width := len(strconv.Itoa(first + len(lines) - 1))
+		for i, text := range lines {
+			number := ""
+			if first > 0 {
+				number = strconv.Itoa(first + i)
+			}
+			p.line(fmt.Sprintf("      %s %s", p.paint(ansiDim, fmt.Sprintf("%*s |", width, number)), text))
+		}
+	}
+	if finding.OriginalSeverity != "" {
+		p.line("      Severity: " + strings.ToLower(finding.Severity) + " (was " + strings.ToLower(finding.OriginalSeverity) + ")")
+	}
+	if finding.Mitigation != "" {
+		p.line("      Mitigation: " + finding.Mitigation)
+	}
+	if finding.Reference != "" {
+		p.line("      Reference: " + finding.Reference)
+	}
+}
+
+func (p *textPrinter) summary(summary ReportSummary) {
+	p.line(p.paint(ansiBold, "Summary"))
+	if p.err != nil {
+		return
+	}
+
+	table := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	row := func(cells ...any) {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = fmt.Sprint(cell)
+		}
+		_, _ = fmt.Fprintln(table, "  "+strings.Join(parts, "\t"))
+	}
+	row("TARGET", "FINDINGS", "FAIL", "WARN", "INFO", "SUPPRESSED", "BASELINED")
+	for _, target := range summary.ByTarget {
+		row(target.Target, target.Total, target.BySeverity["fail"], target.BySeverity["warn"], target.BySeverity["info"], target.Suppressed, target.Baselined)
+	}
+	row("total", summary.Total, summary.BySeverity["fail"], summary.BySeverity["warn"], summary.BySeverity["info"], summary.Suppressed, summary.Baselined)
+	if err := table.Flush(); err != nil {
+		p.err = err
+		return
+	}
+
+	if ruleSet := summary.RuleSet; ruleSet != nil && len(ruleSet.Packs) > 0 {
+		packs := make([]string, 0, len(ruleSet.Packs))
+		for _, pack := range ruleSet.Packs {
+			packs = append(packs, pack.String())
+		}
+		p.line("")
+		p.line("Rule packs: " + strings.Join(packs, ", "))
+	}
+
+	if gate := summary.Gate; gate != nil {
+		status := p.paint(ansiBold, "passed")
+		if !gate.Passed {
+			status = p.paint(ansiBold+ansiRed, "failed")
+		}
+		p.line("")
+		line := fmt.Sprintf("Gate %s: fail_on=%s, highest=%s", status, gate.FailOn, gate.Highest)
+		if gate.Errors > 0 {
+			line += fmt.Sprintf(", errors=%d", gate.Errors)
+		}
+		p.line(line)
+	}
+}
+
+func positionLabel(pos position, fallback string) string {
+	label := displayPath(pos.File)
+	if label == "" {
+		label = fallback
+	}
+	if label == "" || pos.Line <= 0 {
+		return label
+	}
+	label += ":" + strconv.Itoa(pos.Line)
+	if pos.Column > 0 {
+		label += ":" + strconv.Itoa(pos.Column)
+	}
+	return label
+}
+
+// displayPath shortens absolute paths below the working directory.
+func displayPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+type findingFile struct {
+	name   string
+	scopes []*findingScope
+}
+
+type findingScope struct {
+	name     string
+	findings []ReportFinding
+}
+
+// groupFindings keeps the report order of files and scopes and sorts
+// findings within a scope by descending severity.
+func groupFindings(rep Report) []*findingFile {
+	artifacts := map[string]string{}
+	for _, target := range rep.Summary.ByTarget {
+		if len(target.Artifacts) > 0 {
+			artifacts[target.Target] = target.Artifacts[0]
+		}
+	}
+
+	var files []*findingFile
+	fileIndex := map[string]*findingFile{}
+	scopeIndex := map[string]*findingScope{}
+	for _, finding := range rep.Findings {
+		name := displayPath(findingPosition(finding.Location).File)
+		if name == "" {
+			name = displayPath(artifacts[strings.ToLower(finding.Target)])
+		}
+		if name == "" {
+			name = finding.Target
+		}
+		file, ok := fileIndex[name]
+		if !ok {
+			file = &findingFile{name: name}
+			fileIndex[name] = file
+			files = append(files, file)
+		}
+
+		scopeName := ""
+		switch {
+		case finding.Service != "":
+			scopeName = "service " + finding.Service
+		case finding.Stage != "":
+			scopeName = "stage " + finding.Stage
+		}
+		key := name + "\x00" + scopeName
+		scope, ok := scopeIndex[key]
+		if !ok {
+			scope = &findingScope{name: scopeName}
+			scopeIndex[key] = scope
+			file.scopes = append(file.scopes, scope)
+		}
+		scope.findings = append(scope.findings, finding)
+	}
+
+	for _, file := range files {
+		for _, scope := range file.scopes {
+			findings := scope.findings
+			sort.SliceStable(findings, func(i, j int) bool {
+				return entities.ParseSeverity(findings[i].Severity) > entities.ParseSeverity(findings[j].Severity)
+			})
+		}
+	}
+	return files
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestWriteTextGroupsFindings(t *testing.T) {
+	rep := Build([]entities.Finding{
+		{
+			ID: "DF013", Name: "curl without verification", Severity: "warn", Target: "dockerfile", Stage: "build",
+			CodeSample: "RUN curl -fsSL https://example.com/i.sh | sh",
+			Location:   dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 4, Character: 4}, End: dockerfile.Position{Line: 4, Character: 40}},
+			Mitigation: "Verify downloads.",
+		},
+		{
+			ID: "DF012", Name: "curl piped to shell", Severity: "fail", Target: "dockerfile", Stage: "build",
+			CodeSample: "RUN curl -fsSL https://example.com/i.sh | sh",
+			Location:   dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 4, Character: 4}, End: dockerfile.Position{Line: 4, Character: 40}},
+			Reference:  "supply-chain guidance",
+		},
+		{
+			ID: "CP003", Name: "Privileged service", Severity: "fail", Target: "compose", Service: "api", CodeSample: "true",
+			Location: model.Location{Path: "services.api.privileged", File: "compose.prod.yaml", Line: 3, Column: 5, Origin: "set in compose.prod.yaml, overriding compose.yaml"},
+		},
+		{
+			ID: "CP013", Name: "Missing healthcheck", Severity: "warn", Target: "compose", Service: "api", CodeSample: "services.api",
+			Location: model.Location{Path: "services.api", File: "compose.prod.yaml", Line: 2, Column: 3},
+		},
+		{
+			ID: "DF002", Name: "Tag without digest", Severity: "warn", Target: "dockerfile",
+			Suppression: &entities.Suppression{Reason: "vendor image"},
+		},
+	}, WithTarget("dockerfile", "Dockerfile"), WithTarget("compose", "compose.yaml"), WithFailOn(entities.SeverityFail))
+
+	var out bytes.Buffer
+	if err := WriteText(&out, rep, TextOptions{}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	text := out.String()
+
+	for _, want := range []string{
+		"Dockerfile\n  stage build\n    FAIL DF012 curl piped to shell\n      Dockerfile:4:5\n      4 | RUN curl -fsSL https://example.com/i.sh | sh\n      Reference: supply-chain guidance\n    WARN DF013",
+		"compose.prod.yaml\n  service api\n    FAIL CP003 Privileged service\n      compose.prod.yaml:3:5 (set in compose.prod.yaml, overriding compose.yaml)\n      3 | privileged: true\n",
+		"    WARN CP013 Missing healthcheck\n      compose.prod.yaml:2:3\n\n",
+		"Suppressed (1)\n  DF002 Tag without digest - vendor image",
+		"  dockerfile  2         1     1     0     1           0\n",
+		"  total       4         2     2     0     1           0\n",
+		"Gate failed: fail_on=fail, highest=fail\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("text report misses %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "\033[") {
+		t.Fatalf("text report contains ANSI codes without color:\n%s", text)
+	}
+
+	out.Reset()
+	if err := WriteText(&out, rep, TextOptions{Color: true}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if !strings.Contains(out.String(), ansiRed+"FAIL"+ansiReset) {
+		t.Fatalf("colored report misses red severity:\n%s", out.String())
+	}
+}
+
+func TestWriteTextWithoutFindings(t *testing.T) {
+	var out bytes.Buffer
+	rep := Build(nil, WithTarget("dockerfile", "Dockerfile"), WithRuleSet(RuleSetSummary{
+		Version: "sha256:0123456789ab",
+		Packs:   []RulePack{{Name: "baseline", Version: "1.2.0"}, {Name: "team"}},
+	}))
+	if err := WriteText(&out, rep, TextOptions{}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "No findings.\n") || !strings.Contains(out.String(), "  dockerfile  0") {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\nRule packs: baseline 1.2.0, team\n") {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+}
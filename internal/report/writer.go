@@ -0,0 +1,150 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+const (
+	FormatText              = "text"
+	FormatJSON              = "json"
+	FormatSARIF             = "sarif"
+	FormatJUnit             = "junit"
+	FormatGitLabCodeQuality = "gitlab-codequality"
+	FormatCheckstyle        = "checkstyle"
+	FormatHTML              = "html"
+	FormatMarkdown          = "markdown"
+)
+
+// Input is everything one run produced; each format picks what it needs.
+type Input struct {
+	Report Report
+	// Findings are the engine findings before baselining, including
+	// suppressed ones.
+	Findings    []entities.Finding
+	Evaluations []JUnitArtifact
+	Baseline    *Baseline
+
+	SARIF    SARIFOptions
+	Text     TextOptions
+	HTML     HTMLOptions
+	Markdown MarkdownOptions
+}
+
+// Writer renders one report format.
+type Writer interface {
+	Write(w io.Writer, in Input) error
+}
+
+// WriterFunc adapts a plain function to Writer.
+type WriterFunc func(w io.Writer, in Input) error
+
+func (f WriterFunc) Write(w io.Writer, in Input) error {
+	return f(w, in)
+}
+
+var (
+	writersMu sync.RWMutex
+	writers   = map[string]Writer{
+		FormatText: WriterFunc(func(w io.Writer, in Input) error {
+			return WriteText(w, in.Report, in.Text)
+		}),
+		FormatHTML: WriterFunc(func(w io.Writer, in Input) error {
+			return WriteHTML(w, in.Report, in.HTML)
+		}),
+		FormatMarkdown: WriterFunc(func(w io.Writer, in Input) error {
+			return WriteMarkdown(w, in.Report, in.Markdown)
+		}),
+		FormatJSON: marshalWriter(func(in Input) ([]byte, error) {
+			return MarshalJSON(in.Report)
+		}),
+		FormatSARIF: marshalWriter(func(in Input) ([]byte, error) {
+			return MarshalSARIF(BuildSARIF(in.Baseline.WithoutBaselined(in.Findings), in.SARIF))
+		}),
+		FormatJUnit: marshalWriter(func(in Input) ([]byte, error) {
+			return MarshalJUnit(BuildJUnit(in.Evaluations, JUnitOptions{Baseline: in.Baseline}))
+		}),
+		FormatGitLabCodeQuality: marshalWriter(func(in Input) ([]byte, error) {
+			return MarshalCodeQuality(BuildCodeQuality(in.Report))
+		}),
+		FormatCheckstyle: marshalWriter(func(in Input) ([]byte, error) {
+			return MarshalCheckstyle(BuildCheckstyle(in.Report))
+		}),
+	}
+)
+
+// Register adds or replaces the writer for a format name.
+func Register(format string, writer Writer) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	writers[strings.ToLower(strings.TrimSpace(format))] = writer
+}
+
+// Lookup returns the writer registered for format.
+func Lookup(format string) (Writer, error) {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	writer, ok := writers[strings.ToLower(strings.TrimSpace(format))]
+	if !ok {
+		return nil, fmt.Errorf("unknown report format %q: expected one of %s", format, strings.Join(formatsLocked(), ", "))
+	}
+	return writer, nil
+}
+
+// Formats lists the registered format names in sorted order.
+func Formats() []string {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	return formatsLocked()
+}
+
+func formatsLocked() []string {
+	formats := make([]string, 0, len(writers))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Write renders the report in the given format.
+func Write(w io.Writer, format string, in Input) error {
+	writer, err := Lookup(format)
+	if err != nil {
+		return err
+	}
+	return writer.Write(w, in)
+}
+
+// WriteFile renders the report into path. Files never get terminal colors.
+func WriteFile(path string, format string, in Input) error {
+	in.Text.Color = false
+	var buf bytes.Buffer
+	if err := Write(&buf, format, in); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// marshalWriter writes the payload built by marshal in one go, ending it
+// with a newline.
+func marshalWriter(marshal func(in Input) ([]byte, error)) Writer {
+	return WriterFunc(func(w io.Writer, in Input) error {
+		payload, err := marshal(in)
+		if err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(payload, []byte("\n")) {
+			payload = append(payload, '\n')
+		}
+		_, err = w.Write(payload)
+		return err
+	})
+}
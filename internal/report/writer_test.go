@@ -0,0 +1,144 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/compose/model"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func writerTestReport() Report {
+	return Build([]entities.Finding{
+		{
+			ID: "DF001", Name: "Latest tag", Severity: "fail", Target: "dockerfile", CodeSample: "FROM alpine:latest",
+			Location: dockerfile.SourceRef{File: "Dockerfile", Start: dockerfile.Position{Line: 1, Character: 11}, End: dockerfile.Position{Line: 1, Character: 18}},
+		},
+		{
+			ID: "CP003", Name: "Privileged", Severity: "warn", Target: "compose", Service: "api",
+			Location: model.Location{Path: "services.api.privileged", File: "compose.prod.yaml", Line: 3, Column: 5, Origin: "set in compose.prod.yaml, overriding compose.yaml"},
+		},
+		{ID: "DF022", Name: "No healthcheck", Severity: "info", Target: "dockerfile"},
+		{ID: "DF002", Severity: "warn", Target: "dockerfile", Suppression: &entities.Suppression{Reason: "vendor"}},
+	}, WithTarget("dockerfile", "Dockerfile"), WithTarget("compose", "compose.yaml", "compose.prod.yaml"))
+}
+
+func TestBuildCodeQuality(t *testing.T) {
+	rep := writerTestReport()
+	issues := BuildCodeQuality(rep)
+	if len(issues) != 3 {
+		t.Fatalf("issues = %+v, want active findings only", issues)
+	}
+	want := CodeQualityIssue{
+		Description: "Latest tag",
+		CheckName:   "DF001",
+		Fingerprint: rep.Findings[0].Fingerprint,
+		Severity:    "critical",
+		Location:    CodeQualityLocation{Path: "Dockerfile", Lines: CodeQualityLines{Begin: 1}},
+	}
+	if issues[0] != want || issues[0].Fingerprint == "" {
+		t.Fatalf("issue = %+v, want %+v", issues[0], want)
+	}
+	if issues[1].Severity != "info" || issues[1].Location.Path != "Dockerfile" || issues[1].Location.Lines.Begin != 1 {
+		t.Fatalf("issue without location = %+v, want target artifact at line 1", issues[1])
+	}
+	if issues[2].Severity != "major" || issues[2].Location.Path != "compose.prod.yaml" || issues[2].Location.Lines.Begin != 3 ||
+		issues[2].Description != "Privileged (set in compose.prod.yaml, overriding compose.yaml)" {
+		t.Fatalf("compose issue = %+v", issues[2])
+	}
+}
+
+func TestBuildCheckstyle(t *testing.T) {
+	rep := writerTestReport()
+	payload, err := MarshalCheckstyle(BuildCheckstyle(rep))
+	if err != nil {
+		t.Fatalf("MarshalCheckstyle() error = %v", err)
+	}
+	for _, want := range []string{
+		`<checkstyle version="4.3">`,
+		`<file name="Dockerfile">`,
+		`<error line="1" column="12" severity="error" message="Latest tag [` + rep.Findings[0].Fingerprint + `]" source="containsentry.DF001"></error>`,
+		`<error line="1" severity="info"`,
+		`<file name="compose.prod.yaml">`,
+		`<error line="3" column="5" severity="warning"`,
+	} {
+		if !strings.Contains(string(payload), want) {
+			t.Fatalf("checkstyle XML misses %q:\n%s", want, payload)
+		}
+	}
+	if strings.Count(string(payload), "<file ") != 2 {
+		t.Fatalf("checkstyle XML should group findings by file:\n%s", payload)
+	}
+}
+
+func TestWriterRegistry(t *testing.T) {
+	if _, err := Lookup("yaml"); err == nil || !strings.Contains(err.Error(), "gitlab-codequality") {
+		t.Fatalf("Lookup(yaml) error = %v, want unknown format listing known ones", err)
+	}
+
+	Register("count", WriterFunc(func(w io.Writer, in Input) error {
+		_, err := io.WriteString(w, "findings")
+		return err
+	}))
+	t.Cleanup(func() {
+		writersMu.Lock()
+		delete(writers, "count")
+		writersMu.Unlock()
+	})
+	var out bytes.Buffer
+	if err := Write(&out, "Count", Input{}); err != nil || out.String() != "findings" {
+		t.Fatalf("Write(count) = %q, %v", out.String(), err)
+	}
+
+	dir := t.TempDir()
+	in := Input{Report: writerTestReport(), Text: TextOptions{Color: true}}
+	for _, format := range []string{FormatJSON, FormatGitLabCodeQuality, FormatText} {
+		path := filepath.Join(dir, format)
+		if err := WriteFile(path, format, in); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", format, err)
+		}
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", format, err)
+		}
+		if bytes.Contains(payload, []byte("\033[")) {
+			t.Fatalf("%s file contains ANSI codes", format)
+		}
+		if format != FormatText && !json.Valid(payload) {
+			t.Fatalf("%s file is not valid JSON:\n%s", format, payload)
+		}
+	}
+}
+
+func TestWriteSARIFOmitsBaselinedFindings(t *testing.T) {
+	findings := []entities.Finding{
+		{ID: "DF001", Severity: "fail", Target: "dockerfile", CodeSample: "FROM alpine:latest"},
+		{ID: "DF002", Severity: "warn", Target: "dockerfile", CodeSample: "FROM alpine:latest"},
+		{ID: "DF006", Severity: "fail", Target: "dockerfile", CodeSample: "USER root"},
+		{ID: "DF007", Severity: "warn", Target: "dockerfile", CodeSample: "USER root", Suppression: &entities.Suppression{Reason: "init"}},
+	}
+	baseline := NewBaseline(findings[:2])
+	in := Input{Report: Build(findings, WithBaseline(&baseline)), Findings: findings, Baseline: &baseline}
+
+	var out bytes.Buffer
+	if err := Write(&out, FormatSARIF, in); err != nil {
+		t.Fatalf("Write(sarif) error = %v", err)
+	}
+	var log SARIFLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	var ids []string
+	for _, result := range log.Runs[0].Results {
+		ids = append(ids, result.RuleID)
+	}
+	if strings.Join(ids, ",") != "DF006,DF007" {
+		t.Fatalf("results = %v, want DF006 and the suppressed DF007 without baselined findings", ids)
+	}
+}
@@ -0,0 +1,27 @@
+package ruleset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+// Digest identifies the effective rule set, including policy overrides, so
+// that reports can tell which rules produced them. It is "sha256:" followed by
+// the first 12 hex digits of the hash over the rules. A rule that cannot be
+// encoded is an error, so that different rule sets never share a digest.
+func Digest(rules []entities.BaseRule) (string, error) {
+	hash := sha256.New()
+	for i, rule := range rules {
+		payload, err := json.Marshal(rule)
+		if err != nil {
+			return "", fmt.Errorf("digest rule %d: %w", i, err)
+		}
+		hash.Write(payload)
+		hash.Write([]byte{'\n'})
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
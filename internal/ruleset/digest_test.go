@@ -0,0 +1,54 @@
+package ruleset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func TestDigestTracksEffectiveRules(t *testing.T) {
+	rules := []entities.BaseRule{
+		{Target: "dockerfile", Metadata: &entities.Metadata{ID: "DF001", Severity: "fail"}},
+		{Target: "dockerfile", Metadata: &entities.Metadata{ID: "DF002", Severity: "warn"}},
+	}
+	digest := mustDigest(t, rules)
+	if !strings.HasPrefix(digest, "sha256:") || len(digest) != len("sha256:")+12 {
+		t.Fatalf("Digest() = %q", digest)
+	}
+	if mustDigest(t, rules) != digest {
+		t.Fatalf("Digest() is not deterministic")
+	}
+
+	overridden, err := Apply(rules, Policy{Severity: map[string]string{"DF002": "fail"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if mustDigest(t, overridden) == digest || mustDigest(t, rules[:1]) == digest {
+		t.Fatalf("Digest() should change with the effective rules")
+	}
+}
+
+func TestDigestRejectsRulesThatCannotBeEncoded(t *testing.T) {
+	rules := []entities.BaseRule{{Target: "dockerfile", Expression: unencodable{}}}
+	if _, err := Digest(rules); err == nil {
+		t.Fatalf("Digest() error = nil, want encoding error")
+	}
+}
+
+func mustDigest(t *testing.T, rules []entities.BaseRule) string {
+	t.Helper()
+	digest, err := Digest(rules)
+	if err != nil {
+		t.Fatalf("Digest() error = %v", err)
+	}
+	return digest
+}
+
+// unencodable is an expression whose JSON encoding always fails.
+type unencodable struct{ entities.Expression }
+
+func (unencodable) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("not encodable")
+}
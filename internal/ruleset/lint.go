@@ -0,0 +1,612 @@
+package ruleset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"go.yaml.in/yaml/v4"
+)
+
+// Vocabulary describes what the runner of one target evaluates, so that rules
+// it would silently never match can be reported.
+type Vocabulary struct {
+	Subjects []string
+	Kinds    []string
+	// Checks are the dockerfile_constraint checks, if the target has any.
+	Checks []string
+	// Select reports whether the select path of a dsl or field expression
+	// resolves to a value.
+	Select func(path string) bool
+}
+
+// Issue is a problem in a rule file, positioned at the offending JSON value.
+type Issue struct {
+	File    string
+	Line    int
+	Column  int
+	RuleID  string
+	Message string
+}
+
+func (i Issue) String() string {
+	pos := i.File
+	if i.Line > 0 {
+		pos += fmt.Sprintf(":%d:%d", i.Line, i.Column)
+	}
+	if i.RuleID != "" {
+		return fmt.Sprintf("%s: [%s] %s", pos, i.RuleID, i.Message)
+	}
+	return pos + ": " + i.Message
+}
+
+var (
+	ruleFields       = []string{"target", "phase", "subject", "expression", "metadata", "examples"}
+	metadataFields   = []string{"id", "name", "description", "severity", "tags", "mitigation", "reference"}
+	exprNodeFields   = []string{"op", "args", "arg", "where", "left", "right", "name", "type", "target", "has", "missing", "id", "sharing"}
+	matcherFields    = []string{"op", "value", "values", "pattern"}
+	fieldNodeFields  = []string{"op", "args", "arg", "select", "value", "values", "pattern"}
+	knownPhases      = []string{"", "pre", "post"}
+	knownSeverities  = []string{"fail", "error", "critical", "high", "warn", "warning", "medium", "info", "low", "note"}
+	expressionFields = map[string][]string{
+		"regex":                 {"expr_kind", "kind", "type", "expressions"},
+		"user_id_compare":       {"expr_kind", "kind", "operator", "value"},
+		"dockerfile_constraint": {"expr_kind", "kind", "check"},
+		"dsl":                   {"expr_kind", "kind", "select", "expr"},
+		"field":                 {"expr_kind", "kind", "select", "expr"},
+	}
+)
+
+// Lint checks rule files against the vocabularies of their targets: unknown
+// targets, subjects, expression kinds, checks, selects and ops, invalid
+// regexes, missing metadata and rule IDs defined more than once across all
+// files. Only unreadable files are returned as an error.
+func Lint(paths []string, vocabularies map[string]Vocabulary) ([]Issue, error) {
+	files, err := ExpandPaths(paths, "")
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{vocabularies: vocabularies, ids: map[string]Issue{}, visited: map[string]bool{}, readFile: os.ReadFile}
+	return l.run(files)
+}
+
+// LintFS lints the named rule files of fsys, such as the rule packs embedded
+// in the binary. Files read this way may not include other files.
+func LintFS(fsys fs.FS, names []string, vocabularies map[string]Vocabulary) ([]Issue, error) {
+	l := &linter{vocabularies: vocabularies, ids: map[string]Issue{}, visited: map[string]bool{}, embedded: true}
+	l.readFile = func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+	return l.run(names)
+}
+
+type linter struct {
+	vocabularies map[string]Vocabulary
+	ids          map[string]Issue
+	issues       []Issue
+	visited      map[string]bool
+	readFile     func(name string) ([]byte, error)
+	embedded     bool
+
+	file   string
+	ruleID string
+	// items counts the enclosing list quantifiers, inside which item paths
+	// select fields of the bound element.
+	items int
+}
+
+func (l *linter) run(files []string) ([]Issue, error) {
+	for len(files) > 0 {
+		path := files[0]
+		files = files[1:]
+		if l.visited[fileKey(path)] {
+			continue
+		}
+		l.visited[fileKey(path)] = true
+
+		payload, err := l.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read rules file %q: %w", path, err)
+		}
+		files = append(l.lintFile(path, payload), files...)
+	}
+	return l.issues, nil
+}
+
+func (l *linter) report(node *yaml.Node, format string, args ...any) {
+	issue := Issue{File: l.file, RuleID: l.ruleID, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// lintFile lints one rules file and returns the files it includes.
+func (l *linter) lintFile(path string, payload []byte) []string {
+	l.file, l.ruleID = path, ""
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(payload, &doc); err != nil {
+		l.report(nil, "parse rules: %v", err)
+		return nil
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		l.report(nil, "rules file is empty")
+		return nil
+	}
+
+	root := doc.Content[0]
+	var includes []string
+	if root.Kind == yaml.MappingNode {
+		fields := l.fields(root, "$schema", "name", "version", "include", "rules")
+		if include, ok := fields["include"]; ok {
+			if l.embedded {
+				l.report(include, "include is only supported for files on disk")
+			} else {
+				includes = l.includes(include, filepath.Dir(path))
+			}
+		}
+		rules, ok := fields["rules"]
+		if !ok {
+			if includes == nil {
+				l.report(root, "missing rules")
+			}
+			return includes
+		}
+		root = rules
+	}
+	if root.Kind != yaml.SequenceNode {
+		l.report(root, "expected an array of rules or an object with rules")
+		return includes
+	}
+	for _, rule := range root.Content {
+		l.lintRule(rule)
+	}
+	return includes
+}
+
+func (l *linter) includes(node *yaml.Node, baseDir string) []string {
+	var files []string
+	if node.Kind != yaml.SequenceNode {
+		l.report(node, "include must be an array of paths")
+		return nil
+	}
+	for _, include := range node.Content {
+		expanded, err := ExpandPaths([]string{include.Value}, baseDir)
+		if err != nil {
+			l.report(include, "%v", err)
+			continue
+		}
+		for _, file := range expanded {
+			if _, err := os.Stat(file); err != nil {
+				l.report(include, "include %q: %v", include.Value, err)
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (l *linter) lintRule(node *yaml.Node) {
+	l.ruleID = ""
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "rule must be an object")
+		return
+	}
+	reported := len(l.issues)
+
+	fields := l.fields(node, ruleFields...)
+	if metadata, ok := fields["metadata"]; ok && metadata.Kind == yaml.MappingNode {
+		if id, ok := mappingValue(metadata, "id"); ok {
+			l.ruleID = id.Value
+		}
+	}
+	l.lintMetadata(node, fields["metadata"])
+
+	target := strings.ToLower(strings.TrimSpace(scalar(fields["target"])))
+	vocabulary, known := l.vocabularies[target]
+	switch {
+	case fields["target"] == nil:
+		l.report(node, "missing target")
+	case !known:
+		l.report(fields["target"], "unknown target %q: expected one of %s", target, strings.Join(l.targets(), ", "))
+	}
+
+	if phase, ok := fields["phase"]; ok && !containsFold(knownPhases, phase.Value) {
+		l.report(phase, "unknown phase %q: expected pre or post", phase.Value)
+	}
+
+	subject, ok := fields["subject"]
+	switch {
+	case !ok || strings.TrimSpace(subject.Value) == "":
+		l.report(node, "missing subject")
+	case known && !containsFold(vocabulary.Subjects, subject.Value):
+		l.report(subject, "unknown subject %q for target %s", subject.Value, target)
+	}
+
+	if expression, ok := fields["expression"]; ok {
+		l.lintExpression(expression, target, vocabulary, known, scalar(subject))
+	} else {
+		l.report(node, "missing expression")
+	}
+
+	if examples, ok := fields["examples"]; ok {
+		l.lintExamples(examples)
+	}
+
+	// Anything the structural checks missed still fails to load.
+	if len(l.issues) == reported {
+		if err := decodeRule(node); err != nil {
+			l.report(node, "%v", err)
+		}
+	}
+}
+
+func (l *linter) lintMetadata(rule *yaml.Node, metadata *yaml.Node) {
+	if metadata == nil {
+		l.report(rule, "missing metadata")
+		return
+	}
+	if metadata.Kind != yaml.MappingNode {
+		l.report(metadata, "metadata must be an object")
+		return
+	}
+	fields := l.fields(metadata, metadataFields...)
+	for _, name := range []string{"id", "name", "severity"} {
+		if strings.TrimSpace(scalar(fields[name])) == "" {
+			l.report(metadata, "missing metadata.%s", name)
+		}
+	}
+	if severity, ok := fields["severity"]; ok && severity.Value != "" && !containsFold(knownSeverities, severity.Value) {
+		l.report(severity, "unknown severity %q: expected fail, warn or info", severity.Value)
+	}
+
+	id, ok := fields["id"]
+	if !ok || id.Value == "" {
+		return
+	}
+	if first, ok := l.ids[id.Value]; ok {
+		l.report(id, "duplicate rule ID %q, first defined at %s:%d:%d", id.Value, first.File, first.Line, first.Column)
+		return
+	}
+	l.ids[id.Value] = Issue{File: l.file, Line: id.Line, Column: id.Column}
+}
+
+func (l *linter) lintExamples(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "examples must be an object with match and no_match")
+		return
+	}
+	fields := l.fields(node, "match", "no_match")
+	for _, name := range []string{"match", "no_match"} {
+		snippets, ok := fields[name]
+		if ok && (snippets.Kind != yaml.SequenceNode || slices.ContainsFunc(snippets.Content, func(snippet *yaml.Node) bool {
+			return snippet.Kind != yaml.ScalarNode
+		})) {
+			l.report(snippets, "examples.%s must be an array of strings", name)
+		}
+	}
+}
+
+func (l *linter) lintExpression(node *yaml.Node, target string, vocabulary Vocabulary, known bool, subject string) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "expression must be an object")
+		return
+	}
+	kindNode, ok := mappingValue(node, "expr_kind")
+	if !ok {
+		kindNode, ok = mappingValue(node, "kind")
+	}
+	if !ok || kindNode.Value == "" {
+		l.report(node, "missing expr_kind")
+		return
+	}
+	kind := strings.ToLower(kindNode.Value)
+	allowed, ok := expressionFields[kind]
+	if !ok {
+		l.report(kindNode, "unknown expression kind %q: expected one of %s", kindNode.Value, strings.Join(entities.ExpressionKinds, ", "))
+		return
+	}
+	if known && !slices.Contains(vocabulary.Kinds, kind) {
+		l.report(kindNode, "expression kind %s is not evaluated for target %s", kind, target)
+	}
+	fields := l.fields(node, allowed...)
+
+	switch kind {
+	case "regex":
+		if matchType, ok := fields["type"]; ok && matchType.Value != "0" && matchType.Value != "1" {
+			l.report(matchType, "unknown regex type %q: expected 0 (any) or 1 (all)", matchType.Value)
+		}
+		expressions, ok := fields["expressions"]
+		if !ok || expressions.Kind != yaml.SequenceNode || len(expressions.Content) == 0 {
+			l.report(node, "regex expression requires a non-empty expressions array")
+			return
+		}
+		for _, expression := range expressions.Content {
+			l.lintRegex(expression)
+		}
+	case "user_id_compare":
+		operator, ok := fields["operator"]
+		if !ok || !slices.Contains(entities.UserIDOperators, strings.TrimSpace(operator.Value)) {
+			l.report(node, "user_id_compare requires operator, one of %s", strings.Join(entities.UserIDOperators, " "))
+		}
+		if _, ok := fields["value"]; !ok {
+			l.report(node, "user_id_compare requires value")
+		}
+	case "dockerfile_constraint":
+		check, ok := fields["check"]
+		switch {
+		case !ok:
+			l.report(node, "dockerfile_constraint requires check")
+		case known && !slices.Contains(vocabulary.Checks, check.Value):
+			l.report(check, "unknown check %q: expected one of %s", check.Value, strings.Join(vocabulary.Checks, ", "))
+		}
+		if !strings.EqualFold(strings.TrimSpace(subject), "eof") {
+			l.report(node, "dockerfile_constraint is only evaluated on subject eof")
+		}
+	case "dsl", "field":
+		l.lintSelect(node, fields["select"], target, vocabulary, known)
+		expr, ok := fields["expr"]
+		if !ok {
+			l.report(node, "%s expression requires expr", kind)
+			return
+		}
+		if kind == "dsl" {
+			l.lintExprNode(expr)
+		} else {
+			l.lintFieldNode(expr, target, vocabulary, known)
+		}
+	}
+}
+
+func (l *linter) lintSelect(parent *yaml.Node, selectNode *yaml.Node, target string, vocabulary Vocabulary, known bool) {
+	switch {
+	case selectNode == nil || strings.TrimSpace(selectNode.Value) == "":
+		l.report(parent, "missing select")
+	case known && vocabulary.Select != nil && !vocabulary.Select(selectNode.Value):
+		l.report(selectNode, "unknown select %q for target %s", selectNode.Value, target)
+	}
+}
+
+// isItemSelect reports whether path selects the element bound by a list
+// quantifier.
+func isItemSelect(path string) bool {
+	path = strings.TrimSpace(path)
+	return path == "item" || strings.HasPrefix(path, "item.")
+}
+
+func (l *linter) lintExprNode(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "dsl node must be an object")
+		return
+	}
+	fields := l.fields(node, exprNodeFields...)
+	op, ok := l.op(node, fields, entities.ExprNodeOps, "dsl")
+	if !ok {
+		return
+	}
+
+	for _, name := range []string{"name", "target", "id", "sharing"} {
+		if matcher, ok := fields[name]; ok {
+			l.lintMatcher(matcher)
+		}
+	}
+	switch op {
+	case "all", "any":
+		args, ok := fields["args"]
+		if !ok || args.Kind != yaml.SequenceNode {
+			l.report(node, "op %s requires an args array", op)
+			return
+		}
+		for _, arg := range args.Content {
+			l.lintExprNode(arg)
+		}
+	case "not":
+		l.lintExprChild(node, fields, op, "arg")
+	case "exists":
+		l.lintExprChild(node, fields, op, "where")
+	case "pipe":
+		l.lintExprChild(node, fields, op, "left")
+		l.lintExprChild(node, fields, op, "right")
+	case "call":
+		args, ok := fields["args"]
+		if !ok {
+			return
+		}
+		if args.Kind != yaml.MappingNode {
+			l.report(args, "op call requires an args object with any or all")
+			return
+		}
+		callFields := l.fields(args, "any", "all")
+		for _, name := range []string{"any", "all"} {
+			if matchers, ok := callFields[name]; ok {
+				for _, matcher := range matchers.Content {
+					l.lintMatcher(matcher)
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) lintExprChild(node *yaml.Node, fields map[string]*yaml.Node, op string, name string) {
+	child, ok := fields[name]
+	if !ok {
+		l.report(node, "op %s requires %s", op, name)
+		return
+	}
+	l.lintExprNode(child)
+}
+
+func (l *linter) lintMatcher(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "matcher must be an object")
+		return
+	}
+	fields := l.fields(node, matcherFields...)
+	op, ok := l.op(node, fields, entities.MatcherOps, "matcher")
+	if ok && op == "regex" {
+		l.lintPattern(node, fields)
+	}
+}
+
+func (l *linter) lintFieldNode(node *yaml.Node, target string, vocabulary Vocabulary, known bool) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, "field node must be an object")
+		return
+	}
+	fields := l.fields(node, fieldNodeFields...)
+	op, ok := l.op(node, fields, entities.FieldExprNodeOps, "field")
+	if !ok {
+		return
+	}
+
+	switch op {
+	case "all", "any":
+		args, ok := fields["args"]
+		if !ok || args.Kind != yaml.SequenceNode {
+			l.report(node, "op %s requires an args array", op)
+			return
+		}
+		for _, arg := range args.Content {
+			l.lintFieldNode(arg, target, vocabulary, known)
+		}
+	case "field":
+		if selectNode := fields["select"]; l.items == 0 || !isItemSelect(scalar(selectNode)) {
+			l.lintSelect(node, selectNode, target, vocabulary, known)
+		}
+		fallthrough
+	case "not", "len", "any_item", "all_items", "none_item":
+		arg, ok := fields["arg"]
+		if !ok {
+			l.report(node, "op %s requires arg", op)
+			return
+		}
+		if op == "any_item" || op == "all_items" || op == "none_item" {
+			l.items++
+			defer func() { l.items-- }()
+		}
+		l.lintFieldNode(arg, target, vocabulary, known)
+	case "gt", "gte", "lt", "lte":
+		value, ok := fields["value"]
+		if !ok {
+			l.report(node, "op %s requires value", op)
+			return
+		}
+		if _, ok := entities.ParseQuantity(value.Value); value.Kind != yaml.ScalarNode || !ok {
+			l.report(value, "op %s requires a number, duration or byte size value, got %q", op, value.Value)
+		}
+	case "regex":
+		l.lintPattern(node, fields)
+	case "in":
+		if values, ok := fields["values"]; !ok || values.Kind != yaml.SequenceNode {
+			l.report(node, "op in requires a values array")
+		}
+	}
+}
+
+// op validates the op of a node against the known ops and returns it lower-cased.
+func (l *linter) op(node *yaml.Node, fields map[string]*yaml.Node, ops []string, what string) (string, bool) {
+	opNode, ok := fields["op"]
+	if !ok {
+		l.report(node, "missing op")
+		return "", false
+	}
+	op := strings.ToLower(strings.TrimSpace(opNode.Value))
+	if !slices.Contains(ops, op) {
+		l.report(opNode, "unknown %s op %q: expected one of %s", what, opNode.Value, strings.Join(ops, ", "))
+		return "", false
+	}
+	return op, true
+}
+
+func (l *linter) lintPattern(node *yaml.Node, fields map[string]*yaml.Node) {
+	pattern, ok := fields["pattern"]
+	if !ok {
+		l.report(node, "op regex requires pattern")
+		return
+	}
+	l.lintRegex(pattern)
+}
+
+func (l *linter) lintRegex(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode {
+		l.report(node, "regex must be a string")
+		return
+	}
+	if _, err := regexp.Compile(node.Value); err != nil {
+		l.report(node, "invalid regex %q: %v", node.Value, err)
+	}
+}
+
+// fields indexes the values of a mapping by key and reports keys outside
+// allowed at their position.
+func (l *linter) fields(node *yaml.Node, allowed ...string) map[string]*yaml.Node {
+	fields := make(map[string]*yaml.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !slices.Contains(allowed, key.Value) {
+			l.report(key, "unknown field %q", key.Value)
+			continue
+		}
+		fields[key.Value] = node.Content[i+1]
+	}
+	return fields
+}
+
+func (l *linter) targets() []string {
+	targets := make([]string, 0, len(l.vocabularies))
+	for target := range l.vocabularies {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, bool) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// decodeRule loads the rule the way the scanner does.
+func decodeRule(node *yaml.Node) error {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var rule entities.BaseRule
+	return json.Unmarshal(payload, &rule)
+}
+
+// scalar is the value of an optional scalar node.
+func scalar(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	return node.Value
+}
+
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
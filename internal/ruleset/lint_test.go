@@ -0,0 +1,357 @@
+package ruleset_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	containsentry "github.com/katvixlab/contain-sentry"
+	"github.com/katvixlab/contain-sentry/internal/compose"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"github.com/katvixlab/contain-sentry/internal/ruleset"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var shippedRules = []string{"../../dockerfile-rules.json", "../../compose-rules.json"}
+
+const schemaPath = "../../schema/rules.schema.json"
+
+func testVocabularies() map[string]ruleset.Vocabulary {
+	return map[string]ruleset.Vocabulary{
+		"dockerfile": {
+			Subjects: dockerfile.Subjects(),
+			Kinds:    dockerfile.ExpressionKinds(),
+			Checks:   dockerfile.ConstraintChecks(),
+			Select:   dockerfile.KnownSelect,
+		},
+		"compose": {
+			Subjects: compose.Subjects(),
+			Kinds:    compose.ExpressionKinds(),
+			Select:   compose.KnownSelect,
+		},
+	}
+}
+
+func writeRules(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLintShippedRules(t *testing.T) {
+	issues, err := ruleset.Lint(shippedRules, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestLintReportsIssuesWithPositions(t *testing.T) {
+	path := writeRules(t, "rules.json", `{"rules": [
+  {"target": "dockerfile", "subject": "volume",
+   "metadata": {"id": "X1", "name": "a", "severity": "fail"},
+   "expression": {"expr_kind": "regex", "expressions": ["(a"]}},
+  {"target": "compose", "subject": "privileged",
+   "metadata": {"id": "X1", "name": "b"},
+   "expression": {"expr_kind": "field", "select": "service.privilege",
+     "expr": {"op": "any", "args": [{"op": "eqq"}, {"op": "field", "select": "service.origin.image", "arg": {"op": "exists"}}]}}},
+  {"target": "dockerfile", "subject": "run",
+   "metadata": {"id": "X3", "name": "c", "severity": "warn"},
+   "expression": {"kind": "dsl", "select": "run.script", "expr": {"op": "call", "name": {"op": "like"}}}},
+  {"target": "k8s", "subject": "x"}
+]}`)
+
+	issues, err := ruleset.Lint([]string{path}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		if issue.File != path {
+			t.Fatalf("issue file = %q, want %q", issue.File, path)
+		}
+		got = append(got, strings.TrimPrefix(issue.String(), path+":"))
+	}
+	want := []string{
+		`2:39: [X1] unknown subject "volume" for target dockerfile`,
+		"4:57: [X1] invalid regex \"(a\": error parsing regexp: missing closing ): `(a`",
+		`6:16: [X1] missing metadata.severity`,
+		`6:23: [X1] duplicate rule ID "X1", first defined at ` + path + `:3:23`,
+		`7:51: [X1] unknown select "service.privilege" for target compose`,
+		`8:44: [X1] unknown field op "eqq": expected one of all, any, not, field, len, any_item, all_items, none_item, regex, exists, eq, ne, contains, in, gt, gte, lt, lte`,
+		`11:96: [X3] unknown matcher op "like": expected one of eq, contains, in, regex`,
+		`12:3: missing metadata`,
+		`12:14: unknown target "k8s": expected one of compose, dockerfile`,
+		`12:3: missing expression`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintDuplicateIDsAcrossFiles(t *testing.T) {
+	rule := `[{"target": "compose", "subject": "privileged",
+  "metadata": {"id": "CP999", "name": "n", "severity": "warn"},
+  "expression": {"expr_kind": "field", "select": "service.privileged", "expr": {"op": "eq", "value": true}}}]`
+	first := writeRules(t, "a.json", rule)
+	second := writeRules(t, "b.json", rule)
+
+	issues, err := ruleset.Lint([]string{first, second}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].File != second || !strings.Contains(issues[0].Message, first+":2:22") {
+		t.Fatalf("issues = %v, want one duplicate in %s", issues, second)
+	}
+}
+
+func TestLintConstraintOnlyOnEOF(t *testing.T) {
+	path := writeRules(t, "rules.json", `[{"target": "dockerfile", "subject": "user",
+  "metadata": {"id": "DF900", "name": "n", "severity": "warn"},
+  "expression": {"expr_kind": "dockerfile_constraint", "check": "missing_user"}}]`)
+
+	issues, err := ruleset.Lint([]string{path}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	if len(issues) != 2 || !strings.HasPrefix(issues[0].Message, `unknown check "missing_user"`) || issues[1].Message != "dockerfile_constraint is only evaluated on subject eof" {
+		t.Fatalf("issues = %v", issues)
+	}
+}
+
+func TestSchemaValidatesShippedRules(t *testing.T) {
+	compiler := jsonschema.NewCompiler()
+	schema, err := compiler.Compile(schemaPath)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	for _, path := range shippedRules {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		document, err := jsonschema.UnmarshalJSON(file)
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("UnmarshalJSON(%s) error = %v", path, err)
+		}
+		if err := schema.Validate(document); err != nil {
+			t.Errorf("%s does not match the schema: %v", path, err)
+		}
+	}
+}
+
+// TestSchemaMatchesVocabulary keeps the published enums in step with the
+// runners and expression parsers.
+func TestSchemaMatchesVocabulary(t *testing.T) {
+	payload, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(payload, &schema); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	conditional := func(target string) map[string]any {
+		for _, branch := range lookup(t, schema, "$defs", "rule", "allOf").([]any) {
+			if lookup(t, branch, "if", "properties", "target", "const") == target {
+				return lookup(t, branch, "then", "properties").(map[string]any)
+			}
+		}
+		t.Fatalf("no subject constraint for target %s", target)
+		return nil
+	}
+
+	var kinds []string
+	for _, def := range []string{"regexExpression", "userIDCompareExpression", "dockerfileConstraintExpression", "dslExpression", "fieldExpression"} {
+		kinds = append(kinds, lookup(t, schema, "$defs", def, "properties", "expr_kind", "const").(string))
+	}
+
+	checks := []struct {
+		name string
+		got  any
+		want []string
+	}{
+		{name: "expression kinds", got: kinds, want: entities.ExpressionKinds},
+		{name: "dsl ops", got: lookup(t, schema, "$defs", "exprNode", "properties", "op", "enum"), want: entities.ExprNodeOps},
+		{name: "matcher ops", got: lookup(t, schema, "$defs", "matcher", "properties", "op", "enum"), want: entities.MatcherOps},
+		{name: "field ops", got: lookup(t, schema, "$defs", "fieldExprNode", "properties", "op", "enum"), want: entities.FieldExprNodeOps},
+		{name: "user id operators", got: lookup(t, schema, "$defs", "userIDCompareExpression", "properties", "operator", "enum"), want: entities.UserIDOperators},
+		{name: "constraint checks", got: lookup(t, schema, "$defs", "dockerfileConstraintExpression", "properties", "check", "enum"), want: dockerfile.ConstraintChecks()},
+		{name: "dsl selects", got: lookup(t, schema, "$defs", "dslExpression", "properties", "select", "enum"), want: entities.DSLSelects},
+		{name: "compose selects", got: lookup(t, schema, "$defs", "composeSelect", "anyOf").([]any)[0].(map[string]any)["enum"], want: compose.Selects()},
+		{name: "dockerfile subjects", got: lookup(t, conditional("dockerfile"), "subject", "enum"), want: dockerfile.Subjects()},
+		{name: "compose subjects", got: lookup(t, conditional("compose"), "subject", "enum"), want: compose.Subjects()},
+	}
+	for _, check := range checks {
+		got := stringSet(check.got)
+		want := stringSet(check.want)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: schema = %v, code = %v", check.name, got, want)
+		}
+	}
+}
+
+func lookup(t *testing.T, value any, path ...string) any {
+	t.Helper()
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			t.Fatalf("schema path %v: %q is not in an object", path, key)
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func stringSet(value any) []string {
+	var items []string
+	switch value := value.(type) {
+	case []string:
+		items = append(items, value...)
+	case []any:
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+	}
+	sort.Strings(items)
+	return items
+}
+
+func TestLintFollowsYAMLIncludes(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.yaml")
+	if err := os.WriteFile(shared, []byte(`name: shared
+version: 1.0.0
+rules:
+  - target: compose
+    subject: privileged
+    metadata: {id: SH001, name: n, severity: warn}
+    expression: {expr_kind: field, select: service.privilegd, expr: {op: eq, value: true}}
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	team := filepath.Join(dir, "team.yml")
+	if err := os.WriteFile(team, []byte("include: [shared.yaml, missing.yaml]\nowner: team\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	issues, err := ruleset.Lint([]string{team}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, strings.TrimPrefix(issue.String(), dir+"/"))
+	}
+	want := []string{
+		`team.yml:2:1: unknown field "owner"`,
+		`team.yml:1:24: include "missing.yaml": stat ` + filepath.Join(dir, "missing.yaml") + `: no such file or directory`,
+		`shared.yaml:7:44: [SH001] unknown select "service.privilegd" for target compose`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintFS(t *testing.T) {
+	fsys := fstest.MapFS{"pack.yaml": {Data: []byte("include: [other.yaml]\nrules:\n  - {target: compose, subject: privileged}\n")}}
+	issues, err := ruleset.LintFS(fsys, []string{"pack.yaml"}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.LintFS() error = %v", err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"pack.yaml:1:10: include is only supported for files on disk",
+		"pack.yaml:3:5: missing metadata",
+		"pack.yaml:3:5: missing expression",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := ruleset.LintFS(containsentry.DefaultRules, []string{"missing-rules.json"}, testVocabularies()); err == nil {
+		t.Fatal("ruleset.LintFS(missing) error = nil")
+	}
+}
+
+func TestLintComparisonOps(t *testing.T) {
+	path := writeRules(t, "rules.yaml", `- target: compose
+  subject: service
+  metadata: {id: CP901, name: n, severity: warn}
+  expression:
+    expr_kind: field
+    select: service.memory_limit
+    expr:
+      op: any
+      args:
+        - {op: gt, value: 2g}
+        - {op: lte, value: soon}
+        - {op: lt}
+        - {op: len}
+`)
+	issues, err := ruleset.Lint([]string{path}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, strings.TrimPrefix(issue.String(), path+":"))
+	}
+	want := []string{
+		`11:28: [CP901] op lte requires a number, duration or byte size value, got "soon"`,
+		`12:11: [CP901] op lt requires value`,
+		`13:11: [CP901] op len requires arg`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintItemSelects(t *testing.T) {
+	path := writeRules(t, "rules.yaml", `- target: compose
+  subject: ports
+  metadata: {id: CP902, name: n, severity: warn}
+  expression:
+    expr_kind: field
+    select: service.ports
+    expr:
+      op: all
+      args:
+        - {op: any_item, arg: {op: field, select: item.host_ip, arg: {op: eq, value: ""}}}
+        - {op: field, select: item.published, arg: {op: exists}}
+        - {op: none_item}
+`)
+	issues, err := ruleset.Lint([]string{path}, testVocabularies())
+	if err != nil {
+		t.Fatalf("ruleset.Lint() error = %v", err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, strings.TrimPrefix(issue.String(), path+":"))
+	}
+	want := []string{
+		`11:31: [CP902] unknown select "item.published" for target compose`,
+		`12:11: [CP902] op none_item requires arg`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
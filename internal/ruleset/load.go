@@ -0,0 +1,253 @@
+package ruleset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"go.yaml.in/yaml/v4"
+)
+
+// ruleFileExtensions are the files picked up from rule directories.
+var ruleFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// Pack is one loaded rules file. Files in the object form may name and
+// version themselves and include other packs.
+type Pack struct {
+	Name    string
+	Version string
+	File    string
+	// Rules counts the rules defined in the file itself, not its includes.
+	Rules int
+}
+
+// Set is the merged result of loading rule packs.
+type Set struct {
+	Rules []entities.BaseRule
+	// Packs are in load order: included packs precede the pack including them.
+	Packs []Pack
+}
+
+// packFile is the object form of a rules file; a plain array of rules is
+// accepted as well.
+type packFile struct {
+	Schema  string              `json:"$schema,omitempty"`
+	Name    string              `json:"name,omitempty"`
+	Version string              `json:"version,omitempty"`
+	Include []string            `json:"include,omitempty"`
+	Rules   []entities.BaseRule `json:"rules"`
+}
+
+// Load reads rules from JSON or YAML files, directories (every .json, .yaml
+// and .yml file below them) and globs, following include entries relative to
+// the including file. A file reached twice is loaded once; include cycles and
+// rule IDs defined by more than one file are errors.
+func Load(paths []string) (Set, error) {
+	files, err := ExpandPaths(paths, "")
+	if err != nil {
+		return Set{}, err
+	}
+	l := &loader{loaded: map[string]bool{}, ids: map[string]string{}}
+	for _, file := range files {
+		if err := l.load(file, nil); err != nil {
+			return Set{}, err
+		}
+	}
+	return l.set, nil
+}
+
+type loader struct {
+	set    Set
+	loaded map[string]bool
+	ids    map[string]string
+}
+
+func (l *loader) load(path string, stack []string) error {
+	key := fileKey(path)
+	if slices.Contains(stack, key) {
+		return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), key)
+	}
+	if l.loaded[key] {
+		return nil
+	}
+	l.loaded[key] = true
+
+	pack, err := readPack(path)
+	if err != nil {
+		return err
+	}
+	includes, err := ExpandPaths(pack.Include, filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("rules file %q: %w", path, err)
+	}
+	for _, include := range includes {
+		if err := l.load(include, append(stack, key)); err != nil {
+			return err
+		}
+	}
+	return l.add(path, pack)
+}
+
+func (l *loader) add(path string, pack packFile) error {
+	for _, rule := range pack.Rules {
+		if id := ruleID(rule); id != "" {
+			if first, ok := l.ids[id]; ok {
+				return fmt.Errorf("duplicate rule ID %q: defined in %s and %s", id, first, path)
+			}
+			l.ids[id] = path
+		}
+	}
+	l.set.Rules = append(l.set.Rules, pack.Rules...)
+	l.set.Packs = append(l.set.Packs, Pack{Name: pack.Name, Version: pack.Version, File: path, Rules: len(pack.Rules)})
+	return nil
+}
+
+// LoadFS reads the named rule files from fsys, such as the rule packs embedded
+// in the binary. Files read this way may not include other files.
+func LoadFS(fsys fs.FS, names ...string) (Set, error) {
+	l := &loader{loaded: map[string]bool{}, ids: map[string]string{}}
+	for _, name := range names {
+		payload, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return Set{}, fmt.Errorf("read rules file %q: %w", name, err)
+		}
+		pack, err := parsePack(name, payload)
+		if err != nil {
+			return Set{}, err
+		}
+		if len(pack.Include) > 0 {
+			return Set{}, fmt.Errorf("rules file %q: include is only supported for files on disk", name)
+		}
+		if err := l.add(name, pack); err != nil {
+			return Set{}, err
+		}
+	}
+	return l.set, nil
+}
+
+// Merge extends base with overlay: a rule with the ID of a base rule replaces
+// it in place, any other rule is appended. The packs of both sets are kept.
+func Merge(base Set, overlay Set) Set {
+	rules := slices.Clone(base.Rules)
+	index := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		if id := ruleID(rule); id != "" {
+			index[id] = i
+		}
+	}
+	for _, rule := range overlay.Rules {
+		if i, ok := index[ruleID(rule)]; ok && ruleID(rule) != "" {
+			rules[i] = rule
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return Set{Rules: rules, Packs: append(slices.Clone(base.Packs), overlay.Packs...)}
+}
+
+func readPack(path string) (packFile, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return packFile{}, fmt.Errorf("read rules file %q: %w", path, err)
+	}
+	return parsePack(path, payload)
+}
+
+func parsePack(path string, payload []byte) (packFile, error) {
+	var err error
+	if isYAML(path) {
+		// Rules unmarshal from JSON only, so YAML goes through a generic value.
+		var value any
+		if err := yaml.Unmarshal(payload, &value); err != nil {
+			return packFile{}, fmt.Errorf("parse rules file %q: %w", path, err)
+		}
+		if payload, err = json.Marshal(value); err != nil {
+			return packFile{}, fmt.Errorf("parse rules file %q: %w", path, err)
+		}
+	}
+
+	var pack packFile
+	if trimmed := bytes.TrimSpace(payload); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(payload, &pack.Rules)
+	} else {
+		err = json.Unmarshal(payload, &pack)
+	}
+	if err != nil {
+		return packFile{}, fmt.Errorf("unmarshal rules file %q: %w", path, err)
+	}
+	return pack, nil
+}
+
+// ExpandPaths resolves rule paths into files: directories are walked for
+// rule files and globs are matched, both in lexical order. Relative paths are
+// resolved against baseDir when it is set.
+func ExpandPaths(paths []string, baseDir string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if baseDir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if strings.ContainsAny(path, "*?[") {
+			matches, err := filepath.Glob(path)
+			if err != nil {
+				return nil, fmt.Errorf("rules glob %q: %w", path, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no rules files match %q", path)
+			}
+			files = append(files, matches...)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			// Missing files fail with a clear error when they are read.
+			files = append(files, path)
+			continue
+		}
+		found := 0
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && slices.Contains(ruleFileExtensions, strings.ToLower(filepath.Ext(file))) {
+				files = append(files, file)
+				found++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read rules directory %q: %w", path, err)
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("no rules files in directory %q", path)
+		}
+	}
+	return files, nil
+}
+
+func ruleID(rule entities.BaseRule) string {
+	if rule.Metadata == nil {
+		return ""
+	}
+	return rule.Metadata.ID
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// fileKey identifies a file regardless of how it was referenced.
+func fileKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
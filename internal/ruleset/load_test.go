@@ -0,0 +1,161 @@
+package ruleset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return root
+}
+
+const yamlPack = `name: team
+version: 2.1.0
+include:
+  - shared/
+rules:
+  - target: dockerfile
+    subject: from
+    metadata:
+      id: TEAM001
+      name: Base image from public registry
+      severity: warn
+    expression:
+      expr_kind: regex
+      expressions:
+        - (?i)^FROM\s+docker\.io/
+`
+
+const jsonRule = `[{"target": "dockerfile", "subject": "user",
+  "metadata": {"id": "%s", "name": "n", "severity": "warn"},
+  "expression": {"expr_kind": "user_id_compare", "operator": "<=", "value": 1000}}]`
+
+func TestLoadYAMLPackWithIncludes(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"team.yaml":        yamlPack,
+		"shared/a.json":    strings.Replace(jsonRule, "%s", "SH001", 1),
+		"shared/b.yml":     "- target: compose\n  subject: privileged\n  metadata: {id: SH002, name: n, severity: fail}\n  expression: {expr_kind: field, select: service.privileged, expr: {op: eq, value: true}}\n",
+		"shared/notes.txt": "not a rules file",
+	})
+
+	set, err := Load([]string{filepath.Join(root, "team.yaml")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := ruleIDs(set.Rules); strings.Join(got, ",") != "SH001,SH002,TEAM001" {
+		t.Fatalf("rules = %v, want includes first", got)
+	}
+	regex, ok := set.Rules[2].Expression.(*entities.ExpressionRegex)
+	if !ok || !regex.Match("FROM docker.io/library/nginx") {
+		t.Fatalf("YAML regex expression = %#v", set.Rules[2].Expression)
+	}
+	if value := set.Rules[0].Expression.(*entities.ExpressionUserIDCompare).Value; value != 1000 {
+		t.Fatalf("user id value = %d, want 1000", value)
+	}
+
+	last := set.Packs[len(set.Packs)-1]
+	if len(set.Packs) != 3 || last.Name != "team" || last.Version != "2.1.0" || last.Rules != 1 {
+		t.Fatalf("packs = %+v", set.Packs)
+	}
+}
+
+func TestLoadDirectoryAndGlob(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"packs/a.json":  strings.Replace(jsonRule, "%s", "A1", 1),
+		"packs/b.json":  strings.Replace(jsonRule, "%s", "B1", 1),
+		"extra/c.yaml":  "rules: []\ninclude: [../packs/a.json]\n",
+		"extra/d.json":  strings.Replace(jsonRule, "%s", "D1", 1),
+		"extra/e.jsonc": "ignored",
+	})
+
+	set, err := Load([]string{filepath.Join(root, "packs"), filepath.Join(root, "extra", "*.json")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := ruleIDs(set.Rules); strings.Join(got, ",") != "A1,B1,D1" {
+		t.Fatalf("rules = %v", got)
+	}
+
+	// a.json is reached directly and through the include of c.yaml.
+	set, err = Load([]string{filepath.Join(root, "packs", "a.json"), filepath.Join(root, "extra", "c.yaml")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := ruleIDs(set.Rules); strings.Join(got, ",") != "A1" {
+		t.Fatalf("rules = %v, want a.json loaded once", got)
+	}
+
+	if _, err := Load([]string{filepath.Join(root, "missing", "*.yaml")}); err == nil || !strings.Contains(err.Error(), "no rules files match") {
+		t.Fatalf("Load(unmatched glob) error = %v", err)
+	}
+}
+
+func TestLoadRejectsDuplicateIDsAndCycles(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.json":     strings.Replace(jsonRule, "%s", "DUP1", 1),
+		"b.yaml":     "include: [a.json]\nrules:\n  - {target: dockerfile, subject: user, metadata: {id: DUP1, name: n, severity: warn}, expression: {expr_kind: regex, expressions: [root]}}\n",
+		"loop/x.yml": "include: [y.yml]\n",
+		"loop/y.yml": "include: [x.yml]\n",
+	})
+
+	_, err := Load([]string{filepath.Join(root, "b.yaml")})
+	if err == nil || !strings.Contains(err.Error(), `duplicate rule ID "DUP1"`) || !strings.Contains(err.Error(), "a.json and ") {
+		t.Fatalf("Load(duplicate) error = %v", err)
+	}
+
+	_, err = Load([]string{filepath.Join(root, "loop", "x.yml")})
+	if err == nil || !strings.HasPrefix(err.Error(), "include cycle: ") {
+		t.Fatalf("Load(cycle) error = %v", err)
+	}
+}
+
+func TestLoadFSAndMerge(t *testing.T) {
+	builtin, err := LoadFS(fstest.MapFS{
+		"dockerfile-rules.json": {Data: []byte(strings.Replace(jsonRule, "%s", "DF001", 1))},
+		"compose-rules.json":    {Data: []byte(strings.Replace(jsonRule, "%s", "CP001", 1))},
+	}, "dockerfile-rules.json", "compose-rules.json")
+	if err != nil {
+		t.Fatalf("LoadFS() error = %v", err)
+	}
+
+	root := writeFiles(t, map[string]string{
+		"custom.yaml": "- {target: dockerfile, subject: user, metadata: {id: DF001, name: custom, severity: fail}, expression: {expr_kind: regex, expressions: [root]}}\n" +
+			"- {target: dockerfile, subject: user, metadata: {id: TEAM1, name: n, severity: warn}, expression: {expr_kind: regex, expressions: ['0']}}\n",
+	})
+	custom, err := Load([]string{filepath.Join(root, "custom.yaml")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	merged := Merge(builtin, custom)
+	if got := ruleIDs(merged.Rules); strings.Join(got, ",") != "DF001,CP001,TEAM1" {
+		t.Fatalf("rules = %v, want overrides in place", got)
+	}
+	if merged.Rules[0].Metadata.Name != "custom" || builtin.Rules[0].Metadata.Name != "n" {
+		t.Fatalf("DF001 = %+v, want the custom rule without touching the base set", merged.Rules[0].Metadata)
+	}
+	if len(merged.Packs) != 3 || merged.Packs[0].File != "dockerfile-rules.json" {
+		t.Fatalf("packs = %+v", merged.Packs)
+	}
+
+	_, err = LoadFS(fstest.MapFS{"pack.yaml": {Data: []byte("include: [other.yaml]\n")}}, "pack.yaml")
+	if err == nil || !strings.Contains(err.Error(), "include is only supported for files on disk") {
+		t.Fatalf("LoadFS(include) error = %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package ruleset
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+// Policy narrows and tunes a loaded rule set without editing the shared
+// rule files. Every entry is a selector: a rule ID, a glob over IDs such as
+// DF01*, or a tag (optionally written as tag:secrets).
+type Policy struct {
+	Enable   []string          `yaml:"enable" json:"enable,omitempty" env:"ENABLE_RULES" envSeparator:","`
+	Disable  []string          `yaml:"disable" json:"disable,omitempty" env:"DISABLE_RULES" envSeparator:","`
+	Severity map[string]string `yaml:"severity" json:"severity,omitempty" env:"SEVERITY_OVERRIDES" envSeparator:"," envKeyValSeparator:"="`
+}
+
+// Apply keeps only enabled rules (all rules when Enable is empty), drops
+// disabled ones and applies severity overrides. Selectors that match no rule
+// are ignored, so one policy can serve several rule files.
+//
+// When several severity selectors match a rule, an exact ID wins over a glob
+// and a glob wins over a tag.
+func Apply(rules []entities.BaseRule, policy Policy) ([]entities.BaseRule, error) {
+	overrides, err := parseOverrides(policy.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.BaseRule, 0, len(rules))
+	for _, rule := range rules {
+		if len(policy.Enable) > 0 && !MatchesAny(rule, policy.Enable) {
+			continue
+		}
+		if MatchesAny(rule, policy.Disable) {
+			continue
+		}
+		if severity, ok := overrideFor(rule, overrides); ok && rule.Metadata != nil {
+			metadata := *rule.Metadata
+			if !strings.EqualFold(metadata.Severity, severity) {
+				if metadata.OriginalSeverity == "" {
+					metadata.OriginalSeverity = metadata.Severity
+				}
+				metadata.Severity = severity
+			}
+			rule.Metadata = &metadata
+		}
+		result = append(result, rule)
+	}
+	return result, nil
+}
+
+// MatchesAny reports whether any selector selects the rule.
+func MatchesAny(rule entities.BaseRule, selectors []string) bool {
+	for _, selector := range selectors {
+		if matchRank(rule, selector) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	rankNone = iota
+	rankTag
+	rankGlob
+	rankID
+)
+
+func matchRank(rule entities.BaseRule, selector string) int {
+	if rule.Metadata == nil {
+		return rankNone
+	}
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return rankNone
+	}
+
+	if tag, ok := cutPrefixFold(selector, "tag:"); ok {
+		if hasTag(rule.Metadata.Tags, tag) {
+			return rankTag
+		}
+		return rankNone
+	}
+
+	id := strings.ToUpper(strings.TrimSpace(rule.Metadata.ID))
+	upper := strings.ToUpper(selector)
+	if upper == id {
+		return rankID
+	}
+	if ok, err := path.Match(upper, id); err == nil && ok {
+		return rankGlob
+	}
+	if hasTag(rule.Metadata.Tags, selector) {
+		return rankTag
+	}
+	return rankNone
+}
+
+func hasTag(tags []string, tag string) bool {
+	tag = strings.TrimSpace(tag)
+	for _, item := range tags {
+		if strings.EqualFold(strings.TrimSpace(item), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func cutPrefixFold(value string, prefix string) (string, bool) {
+	if len(value) < len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+		return value, false
+	}
+	return strings.TrimSpace(value[len(prefix):]), true
+}
+
+type override struct {
+	selector string
+	severity string
+}
+
+func parseOverrides(severities map[string]string) ([]override, error) {
+	overrides := make([]override, 0, len(severities))
+	for selector, severity := range severities {
+		normalized := strings.ToLower(strings.TrimSpace(severity))
+		switch normalized {
+		case "fail", "warn", "info":
+		default:
+			return nil, fmt.Errorf("severity override for %q: unknown severity %q", selector, severity)
+		}
+		overrides = append(overrides, override{selector: selector, severity: normalized})
+	}
+	// Map order is random; sorting keeps ties between equally specific
+	// selectors deterministic.
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].selector < overrides[j].selector
+	})
+	return overrides, nil
+}
+
+func overrideFor(rule entities.BaseRule, overrides []override) (string, bool) {
+	best := rankNone
+	severity := ""
+	for _, item := range overrides {
+		if rank := matchRank(rule, item.selector); rank > best {
+			best = rank
+			severity = item.severity
+		}
+	}
+	return severity, best > rankNone
+}
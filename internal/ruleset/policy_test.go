@@ -0,0 +1,112 @@
+package ruleset
+
+import (
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func testRules(ids ...string) []entities.BaseRule {
+	rules := make([]entities.BaseRule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, entities.BaseRule{Metadata: &entities.Metadata{ID: id, Severity: "warn"}})
+	}
+	return rules
+}
+
+func ruleIDs(rules []entities.BaseRule) []string {
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.Metadata.ID)
+	}
+	return ids
+}
+
+func TestApplyEnableAndDisable(t *testing.T) {
+	rules, err := Apply(testRules("DF001", "DF002", "DF003"), Policy{
+		Enable:  []string{"df001", "DF002"},
+		Disable: []string{"DF002"},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := ruleIDs(rules); len(got) != 1 || got[0] != "DF001" {
+		t.Fatalf("rules = %v, want [DF001]", got)
+	}
+}
+
+func TestApplySeverityOverrideDoesNotMutateInput(t *testing.T) {
+	input := testRules("DF001", "DF002")
+	rules, err := Apply(input, Policy{Severity: map[string]string{"DF002": "FAIL", "CP001": "info"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("rules len = %d, want 2", len(rules))
+	}
+	if rules[1].Metadata.Severity != "fail" {
+		t.Fatalf("DF002 severity = %q, want fail", rules[1].Metadata.Severity)
+	}
+	if rules[1].Metadata.OriginalSeverity != "warn" {
+		t.Fatalf("DF002 original severity = %q, want warn", rules[1].Metadata.OriginalSeverity)
+	}
+	if rules[0].Metadata.OriginalSeverity != "" {
+		t.Fatalf("DF001 original severity = %q, want empty", rules[0].Metadata.OriginalSeverity)
+	}
+	if input[1].Metadata.Severity != "warn" {
+		t.Fatalf("input severity mutated to %q", input[1].Metadata.Severity)
+	}
+}
+
+func TestApplySelectsByGlobAndTag(t *testing.T) {
+	input := testRules("DF001", "DF010", "DF011", "DF020")
+	input[0].Metadata.Tags = []string{"supply-chain"}
+	input[3].Metadata.Tags = []string{"build-cache"}
+
+	rules, err := Apply(input, Policy{
+		Enable:  []string{"DF01*", "tag:supply-chain"},
+		Disable: []string{"df011"},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	got := ruleIDs(rules)
+	if len(got) != 2 || got[0] != "DF001" || got[1] != "DF010" {
+		t.Fatalf("rules = %v, want [DF001 DF010]", got)
+	}
+
+	rules, err = Apply(input, Policy{Disable: []string{"build-cache"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := ruleIDs(rules); len(got) != 3 {
+		t.Fatalf("rules = %v, want build-cache rule disabled", got)
+	}
+}
+
+func TestApplySeverityPrefersMostSpecificSelector(t *testing.T) {
+	input := testRules("DF010", "DF011")
+	input[0].Metadata.Tags = []string{"secrets"}
+	input[1].Metadata.Tags = []string{"secrets"}
+
+	rules, err := Apply(input, Policy{Severity: map[string]string{
+		"tag:secrets": "info",
+		"DF01*":       "fail",
+		"DF011":       "warn",
+	}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if rules[0].Metadata.Severity != "fail" {
+		t.Fatalf("DF010 severity = %q, want glob override fail", rules[0].Metadata.Severity)
+	}
+	if rules[1].Metadata.Severity != "warn" || rules[1].Metadata.OriginalSeverity != "" {
+		t.Fatalf("DF011 = %+v, want exact override to original warn", rules[1].Metadata)
+	}
+}
+
+func TestApplyRejectsUnknownSeverity(t *testing.T) {
+	if _, err := Apply(testRules("DF001"), Policy{Severity: map[string]string{"DF001": "urgent"}}); err == nil {
+		t.Fatalf("Apply() error = nil, want error")
+	}
+}
@@ -0,0 +1,159 @@
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/katvixlab/contain-sentry/internal/compose"
+	"github.com/katvixlab/contain-sentry/internal/dockerfile"
+	"github.com/katvixlab/contain-sentry/internal/engine"
+	"github.com/katvixlab/contain-sentry/internal/entities"
+	"go.yaml.in/yaml/v4"
+)
+
+// fromInstruction detects whether a Dockerfile example declares its own stage.
+var fromInstruction = regexp.MustCompile(`(?im)^\s*FROM\s`)
+
+// Result is the outcome of one rule example.
+type Result struct {
+	RuleID string
+	Target string
+	// Match is true for examples the rule must report.
+	Match   bool
+	Index   int
+	Example string
+	// Findings counts the unsuppressed findings of the rule on the example.
+	Findings int
+	Err      error
+}
+
+func (r Result) Passed() bool {
+	return r.Err == nil && (r.Findings > 0) == r.Match
+}
+
+func (r Result) String() string {
+	name := fmt.Sprintf("%s no_match[%d]", r.RuleID, r.Index)
+	if r.Match {
+		name = fmt.Sprintf("%s match[%d]", r.RuleID, r.Index)
+	}
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", name, r.Err)
+	case r.Passed():
+		return name + ": ok"
+	case r.Match:
+		return name + ": expected a finding, got none"
+	default:
+		return fmt.Sprintf("%s: expected no finding, got %d", name, r.Findings)
+	}
+}
+
+// Run evaluates every example of every rule with the driver and runner of the
+// rule target, exactly as a scan would, and reports one result per example.
+// Only a failure to create the scratch directory is returned as an error.
+func Run(ctx context.Context, rules []entities.BaseRule) ([]Result, error) {
+	dir, err := os.MkdirTemp("", "containsentry-rules-test-")
+	if err != nil {
+		return nil, fmt.Errorf("create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var results []Result
+	for _, rule := range rules {
+		if rule.Examples == nil {
+			continue
+		}
+		id := ""
+		if rule.Metadata != nil {
+			id = rule.Metadata.ID
+		}
+		for _, example := range []struct {
+			match    bool
+			snippets []string
+		}{{true, rule.Examples.Match}, {false, rule.Examples.NoMatch}} {
+			for i, snippet := range example.snippets {
+				result := Result{RuleID: id, Target: rule.Target, Match: example.match, Index: i, Example: snippet}
+				result.Findings, result.Err = evaluate(ctx, dir, rule, snippet)
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+func evaluate(ctx context.Context, dir string, rule entities.BaseRule, snippet string) (int, error) {
+	driver, err := exampleDriver(ctx, dir, strings.ToLower(strings.TrimSpace(rule.Target)), snippet)
+	if err != nil {
+		return 0, err
+	}
+	evaluation, err := engine.New([]entities.BaseRule{rule}, &dockerfile.DockerfileRunner{}, &compose.ComposeRunner{}).Evaluate(ctx, driver)
+	if err != nil {
+		return 0, err
+	}
+	findings := 0
+	for _, finding := range evaluation.Findings {
+		if finding.Suppression == nil {
+			findings++
+		}
+	}
+	return findings, nil
+}
+
+// exampleDriver writes the snippet where the target loader expects a file
+// and returns the driver over it.
+func exampleDriver(ctx context.Context, dir string, target string, snippet string) (engine.Driver, error) {
+	switch target {
+	case "dockerfile":
+		path := filepath.Join(dir, "Dockerfile")
+		if err := os.WriteFile(path, []byte(dockerfileExample(snippet)), 0o644); err != nil {
+			return nil, err
+		}
+		df, err := dockerfile.NewDockerfile(ctx, path, dockerfile.WithBaseDir(dir))
+		if err != nil {
+			return nil, fmt.Errorf("parse example: %w", err)
+		}
+		return df.Driver(), nil
+	case "compose":
+		path := filepath.Join(dir, "compose.yaml")
+		if err := os.WriteFile(path, []byte(composeExample(snippet)), 0o644); err != nil {
+			return nil, err
+		}
+		project, err := compose.NewProject(ctx, []string{path}, compose.WithBaseDir(dir))
+		if err != nil {
+			return nil, fmt.Errorf("load example: %w", err)
+		}
+		return project.Driver(), nil
+	default:
+		return nil, fmt.Errorf("examples are not supported for target %q", target)
+	}
+}
+
+// dockerfileExample lets fragments such as a single RUN line stand alone by
+// giving them a stage.
+func dockerfileExample(snippet string) string {
+	if fromInstruction.MatchString(snippet) {
+		return snippet
+	}
+	return "FROM scratch\n" + snippet
+}
+
+// composeExample accepts a whole Compose file or the body of one service,
+// which is then named "example".
+func composeExample(snippet string) string {
+	var document map[string]any
+	if err := yaml.Unmarshal([]byte(snippet), &document); err == nil {
+		if _, ok := document["services"]; ok {
+			return snippet
+		}
+	}
+	var b strings.Builder
+	b.WriteString("services:\n  example:\n")
+	for _, line := range strings.Split(strings.TrimRight(snippet, "\n"), "\n") {
+		b.WriteString("    " + line + "\n")
+	}
+	return b.String()
+}
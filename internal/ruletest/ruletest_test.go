@@ -0,0 +1,112 @@
+package ruletest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/katvixlab/contain-sentry/internal/entities"
+)
+
+func loadRules(t *testing.T, path string) []entities.BaseRule {
+	t.Helper()
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var rules []entities.BaseRule
+	if err := json.Unmarshal(payload, &rules); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return rules
+}
+
+func TestRunShippedExamples(t *testing.T) {
+	for _, path := range []string{"../../dockerfile-rules.json", "../../compose-rules.json"} {
+		results, err := Run(context.Background(), loadRules(t, path))
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if len(results) == 0 {
+			t.Fatalf("%s: no examples", path)
+		}
+		for _, result := range results {
+			if !result.Passed() {
+				t.Errorf("%s: %s", path, result)
+			}
+		}
+	}
+}
+
+func TestRunReportsMismatches(t *testing.T) {
+	rule := entities.BaseRule{
+		Target:  "compose",
+		Phase:   "post",
+		Subject: "privileged",
+		Metadata: &entities.Metadata{
+			ID:       "CP003",
+			Severity: "fail",
+		},
+		Expression: &entities.ExpressionField{
+			ExprKind: "field",
+			Select:   "service.privileged",
+			Expr:     &entities.FieldExprNode{Op: "eq", Value: true},
+		},
+		Examples: &entities.Examples{
+			Match: []string{
+				"services:\n  web:\n    image: nginx:1.27\n    privileged: true\n",
+				"image: nginx:1.27",
+			},
+			NoMatch: []string{
+				"image: nginx:1.27\nprivileged: true",
+				"image: [",
+			},
+		},
+	}
+
+	results, err := Run(context.Background(), []entities.BaseRule{rule})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("results = %v, want 4", results)
+	}
+	if !results[0].Passed() {
+		t.Fatalf("full compose file: %s", results[0])
+	}
+	if got := results[1].String(); got != "CP003 match[1]: expected a finding, got none" {
+		t.Fatalf("results[1] = %q", got)
+	}
+	if got := results[2].String(); got != "CP003 no_match[0]: expected no finding, got 1" {
+		t.Fatalf("results[2] = %q", got)
+	}
+	if results[3].Err == nil || !strings.HasPrefix(results[3].String(), "CP003 no_match[1]: load example:") {
+		t.Fatalf("results[3] = %q, want a load error", results[3])
+	}
+}
+
+func TestRunDockerfileFragment(t *testing.T) {
+	rule := entities.BaseRule{
+		Target:     "dockerfile",
+		Phase:      "post",
+		Subject:    "run",
+		Metadata:   &entities.Metadata{ID: "DF019", Severity: "warn"},
+		Expression: &entities.ExpressionRegex{ExprKind: "regex", Expressions: []string{`apk add(?:\s|$)`}},
+		Examples: &entities.Examples{
+			Match:   []string{"RUN apk add curl"},
+			NoMatch: []string{"FROM alpine:3.20\nRUN echo ok"},
+		},
+	}
+
+	results, err := Run(context.Background(), []entities.BaseRule{rule})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, result := range results {
+		if !result.Passed() {
+			t.Fatalf("%s", result)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// Package containsentry ships the default rule packs with the binary.
+package containsentry
+
+import "embed"
+
+// DefaultRules holds the built-in <target>-rules.json of every target.
+//
+//go:embed dockerfile-rules.json compose-rules.json
+var DefaultRules embed.FS